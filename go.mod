@@ -2,4 +2,7 @@ module stashclip
 
 go 1.21
 
-require github.com/BurntSushi/xgb v0.0.0-20210121224620-deaf085860bc
+require (
+	github.com/BurntSushi/xgb v0.0.0-20210121224620-deaf085860bc
+	github.com/godbus/dbus/v5 v5.1.0
+)
@@ -0,0 +1,145 @@
+package actions
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"stashclip/internal/classify"
+	"stashclip/internal/config"
+	"stashclip/internal/i18n"
+)
+
+// Target is the entry an action runs on.
+type Target struct {
+	ID   int
+	Text string
+	Kind classify.Kind
+}
+
+// Action is a named operation available for some content kinds.
+type Action struct {
+	Name  string
+	Label string
+	// Kinds lists the kinds the action applies to; empty means every kind.
+	Kinds []classify.Kind
+	Run   func(t Target) error
+}
+
+// Applies reports whether the action is available for kind.
+func (a Action) Applies(kind classify.Kind) bool {
+	if len(a.Kinds) == 0 {
+		return true
+	}
+	for _, k := range a.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// builtins hold message keys as labels; For translates them.
+var builtins = []Action{
+	{Name: "open", Label: "action.open.url", Kinds: []classify.Kind{classify.KindURL}, Run: openURL},
+	{Name: "open", Label: "action.open.path", Kinds: []classify.Kind{classify.KindPath}, Run: openPath},
+	{Name: "reveal", Label: "action.reveal", Kinds: []classify.Kind{classify.KindPath}, Run: revealPath},
+	{Name: "compose", Label: "action.compose", Kinds: []classify.Kind{classify.KindEmail}, Run: composeMail},
+	{Name: "preview", Label: "action.color", Kinds: []classify.Kind{classify.KindColor}, Run: previewColor},
+}
+
+// For returns the built-in and configured actions available for kind.
+// Configured actions with the same name override built-ins.
+func For(kind classify.Kind, custom []config.Action) []Action {
+	var out []Action
+	overridden := map[string]bool{}
+	for _, c := range custom {
+		a := fromConfig(c)
+		if a.Applies(kind) {
+			out = append(out, a)
+			overridden[a.Name] = true
+		}
+	}
+	var list []Action
+	for _, a := range builtins {
+		if a.Applies(kind) && !overridden[a.Name] {
+			a.Label = i18n.T(a.Label)
+			list = append(list, a)
+		}
+	}
+	return append(list, out...)
+}
+
+// Run executes the action called name on t.
+func Run(name string, t Target, custom []config.Action) error {
+	for _, a := range For(t.Kind, custom) {
+		if a.Name == name {
+			return a.Run(t)
+		}
+	}
+	return fmt.Errorf("no action %q for kind %s", name, t.Kind)
+}
+
+// Validate checks configured actions.
+func Validate(custom []config.Action) error {
+	for _, c := range custom {
+		if c.Name == "" {
+			return fmt.Errorf("action: name is required")
+		}
+		if len(c.Command) == 0 {
+			return fmt.Errorf("action %s: command is required", c.Name)
+		}
+		if c.Kind != "" && c.Kind != "*" {
+			if _, ok := classify.ParseKind(c.Kind); !ok {
+				return fmt.Errorf("action %s: unknown kind: %s", c.Name, c.Kind)
+			}
+		}
+	}
+	return nil
+}
+
+func fromConfig(c config.Action) Action {
+	a := Action{Name: c.Name, Label: c.Label}
+	if a.Label == "" {
+		a.Label = c.Name
+	}
+	if c.Kind != "" && c.Kind != "*" {
+		a.Kinds = []classify.Kind{classify.Kind(c.Kind)}
+	}
+	command := c.Command
+	a.Run = func(t Target) error {
+		return runCommand(command, t)
+	}
+	return a
+}
+
+// runCommand runs a configured action command. The entry text is passed on
+// stdin and substituted for {text} in arguments.
+func runCommand(command []string, t Target) error {
+	if len(command) == 0 {
+		return fmt.Errorf("empty command")
+	}
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = strings.ReplaceAll(arg, "{text}", t.Text)
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(t.Text)
+	cmd.Env = append(os.Environ(),
+		"STASHCLIP_ENTRY_ID="+strconv.Itoa(t.ID),
+		"STASHCLIP_KIND="+string(t.Kind),
+	)
+	cmd.Stdout = os.Stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
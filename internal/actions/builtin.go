@@ -0,0 +1,229 @@
+package actions
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func openURL(t Target) error {
+	u := strings.TrimSpace(t.Text)
+	if strings.HasPrefix(strings.ToLower(u), "www.") {
+		u = "http://" + u
+	}
+	return xdgOpen(u)
+}
+
+func openPath(t Target) error {
+	return xdgOpen(expandPath(t.Text))
+}
+
+// revealPath asks the file manager to select the path through the
+// org.freedesktop.FileManager1 interface, falling back to opening its directory.
+func revealPath(t Target) error {
+	path := expandPath(t.Text)
+	if _, err := exec.LookPath("dbus-send"); err == nil {
+		uri := (&url.URL{Scheme: "file", Path: path}).String()
+		err := exec.Command("dbus-send", "--session", "--print-reply",
+			"--dest=org.freedesktop.FileManager1",
+			"/org/freedesktop/FileManager1",
+			"org.freedesktop.FileManager1.ShowItems",
+			"array:string:"+uri, "string:").Run()
+		if err == nil {
+			return nil
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	return xdgOpen(path)
+}
+
+func composeMail(t Target) error {
+	addr := strings.TrimPrefix(strings.TrimSpace(t.Text), "mailto:")
+	return xdgOpen("mailto:" + addr)
+}
+
+// previewColor renders a swatch of the color to a PNG and opens it.
+func previewColor(t Target) error {
+	c, err := parseColor(t.Text)
+	if err != nil {
+		return err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+	for y := 0; y < 256; y++ {
+		for x := 0; x < 256; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	f, err := os.CreateTemp("", "stashclip-color-*.png")
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return xdgOpen(f.Name())
+}
+
+func xdgOpen(target string) error {
+	if _, err := exec.LookPath("xdg-open"); err != nil {
+		return fmt.Errorf("xdg-open not found")
+	}
+	return exec.Command("xdg-open", target).Run()
+}
+
+func expandPath(text string) string {
+	path := strings.TrimSpace(text)
+	if strings.HasPrefix(path, "file://") {
+		if u, err := url.Parse(path); err == nil {
+			return u.Path
+		}
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// parseColor parses #rgb[a], #rrggbb[aa], rgb()/rgba() and hsl()/hsla().
+func parseColor(text string) (color.NRGBA, error) {
+	s := strings.ToLower(strings.TrimSpace(text))
+	if strings.HasPrefix(s, "#") {
+		return parseHexColor(s[1:])
+	}
+	open := strings.Index(s, "(")
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return color.NRGBA{}, fmt.Errorf("invalid color: %s", text)
+	}
+	fn := s[:open]
+	parts := strings.FieldsFunc(s[open+1:len(s)-1], func(r rune) bool {
+		return r == ',' || r == ' ' || r == '/'
+	})
+	if len(parts) < 3 {
+		return color.NRGBA{}, fmt.Errorf("invalid color: %s", text)
+	}
+	alpha := uint8(255)
+	if len(parts) > 3 {
+		a, err := parseComponent(parts[3], 1)
+		if err != nil {
+			return color.NRGBA{}, err
+		}
+		alpha = uint8(a * 255)
+	}
+	switch fn {
+	case "rgb", "rgba":
+		var rgb [3]uint8
+		for i := 0; i < 3; i++ {
+			v, err := parseComponent(parts[i], 255)
+			if err != nil {
+				return color.NRGBA{}, err
+			}
+			rgb[i] = uint8(v)
+		}
+		return color.NRGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: alpha}, nil
+	case "hsl", "hsla":
+		h, err := strconv.ParseFloat(strings.TrimSuffix(parts[0], "deg"), 64)
+		if err != nil {
+			return color.NRGBA{}, err
+		}
+		sat, err := parseComponent(parts[1], 1)
+		if err != nil {
+			return color.NRGBA{}, err
+		}
+		light, err := parseComponent(parts[2], 1)
+		if err != nil {
+			return color.NRGBA{}, err
+		}
+		r, g, b := hslToRGB(h, sat, light)
+		return color.NRGBA{R: r, G: g, B: b, A: alpha}, nil
+	}
+	return color.NRGBA{}, fmt.Errorf("invalid color: %s", text)
+}
+
+func parseHexColor(hex string) (color.NRGBA, error) {
+	if len(hex) == 3 || len(hex) == 4 {
+		var b strings.Builder
+		for _, r := range hex {
+			b.WriteRune(r)
+			b.WriteRune(r)
+		}
+		hex = b.String()
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return color.NRGBA{}, fmt.Errorf("invalid color: #%s", hex)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color: #%s", hex)
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// parseComponent parses a number or percentage, clamped to [0, max].
+func parseComponent(s string, max float64) (float64, error) {
+	scale := 1.0
+	if strings.HasSuffix(s, "%") {
+		s = strings.TrimSuffix(s, "%")
+		scale = max / 100
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid color component: %s", s)
+	}
+	v *= scale
+	if v < 0 {
+		v = 0
+	}
+	if v > max {
+		v = max
+	}
+	return v, nil
+}
+
+func hslToRGB(h, s, l float64) (uint8, uint8, uint8) {
+	h = math.Mod(math.Mod(h, 360)+360, 360)
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return uint8((r + m) * 255), uint8((g + m) * 255), uint8((b + m) * 255)
+}
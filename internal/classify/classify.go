@@ -0,0 +1,157 @@
+package classify
+
+import (
+	"encoding/json"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Kind is the detected content type of an entry.
+type Kind string
+
+const (
+	KindText   Kind = "text"
+	KindURL    Kind = "url"
+	KindEmail  Kind = "email"
+	KindPath   Kind = "path"
+	KindColor  Kind = "color"
+	KindPhone  Kind = "phone"
+	KindUUID   Kind = "uuid"
+	KindJSON   Kind = "json"
+	KindCode   Kind = "code"
+	KindNumber Kind = "number"
+)
+
+// Kinds lists every kind in detection order.
+var Kinds = []Kind{KindURL, KindEmail, KindUUID, KindColor, KindPath, KindNumber, KindPhone, KindJSON, KindCode, KindText}
+
+// Result is the classification of a text.
+type Result struct {
+	Kind Kind
+	// Language is the guessed language when Kind is KindCode.
+	Language string
+}
+
+var (
+	uuidPattern   = regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	hexColor      = regexp.MustCompile(`^#(?i)([0-9a-f]{3}|[0-9a-f]{4}|[0-9a-f]{6}|[0-9a-f]{8})$`)
+	funcColor     = regexp.MustCompile(`^(?i)(rgba?|hsla?)\(\s*[\d.]+%?\s*[, ]\s*[\d.]+%?\s*[, ]\s*[\d.]+%?\s*([,/]\s*[\d.]+%?\s*)?\)$`)
+	pathPattern   = regexp.MustCompile(`^(~|\.{1,2})?/[^\x00\n]*$`)
+	numberPattern = regexp.MustCompile(`^[-+]?(\d{1,3}([,_]\d{3})+|\d+)?([.,]\d+)?([eE][-+]?\d+)?$`)
+	phonePattern  = regexp.MustCompile(`^\+?[\d\s().-]+$`)
+	datePattern   = regexp.MustCompile(`^(\d{4}[./-]\d{1,2}[./-]\d{1,2}|\d{1,2}[./-]\d{1,2}[./-]\d{2,4})$`)
+	dottedQuad    = regexp.MustCompile(`^\d{1,3}(\.\d{1,3}){3}$`)
+)
+
+// Classify detects the kind of text.
+func Classify(text string) Result {
+	s := strings.TrimSpace(text)
+	if s == "" {
+		return Result{Kind: KindText}
+	}
+	singleLine := !strings.ContainsAny(s, "\n\r")
+	if singleLine {
+		switch {
+		case isURL(s):
+			return Result{Kind: KindURL}
+		case isEmail(s):
+			return Result{Kind: KindEmail}
+		case uuidPattern.MatchString(s):
+			return Result{Kind: KindUUID}
+		case hexColor.MatchString(s) || funcColor.MatchString(s):
+			return Result{Kind: KindColor}
+		case isPath(s):
+			return Result{Kind: KindPath}
+		case strings.ContainsAny(s, "0123456789") && numberPattern.MatchString(s):
+			return Result{Kind: KindNumber}
+		case isPhone(s):
+			return Result{Kind: KindPhone}
+		}
+	}
+	if (s[0] == '{' || s[0] == '[') && json.Valid([]byte(s)) {
+		return Result{Kind: KindJSON}
+	}
+	if lang := guessLanguage(s); lang != "" {
+		return Result{Kind: KindCode, Language: lang}
+	}
+	return Result{Kind: KindText}
+}
+
+// Label returns a short badge for the classification, such as "code/go".
+func (r Result) Label() string {
+	if r.Language != "" {
+		return string(r.Kind) + "/" + r.Language
+	}
+	return string(r.Kind)
+}
+
+// ParseKind validates a kind name.
+func ParseKind(s string) (Kind, bool) {
+	k := Kind(strings.ToLower(strings.TrimSpace(s)))
+	for _, known := range Kinds {
+		if k == known {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func isURL(s string) bool {
+	if strings.ContainsAny(s, " \t") {
+		return false
+	}
+	if strings.HasPrefix(strings.ToLower(s), "www.") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ftp", "ftps", "ssh", "git", "ws", "wss":
+		return true
+	}
+	return false
+}
+
+func isEmail(s string) bool {
+	s = strings.TrimPrefix(s, "mailto:")
+	if strings.ContainsAny(s, " \t<>") || !strings.Contains(s, "@") {
+		return false
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	domain := s[strings.LastIndex(s, "@")+1:]
+	return strings.Contains(domain, ".")
+}
+
+func isPath(s string) bool {
+	if strings.HasPrefix(s, "file://") {
+		return true
+	}
+	if s == "/" || s == "~" {
+		return true
+	}
+	return pathPattern.MatchString(s) && !strings.Contains(s, "//")
+}
+
+func isPhone(s string) bool {
+	// Dotted quads are IPv4 addresses (or look like one), not numbers to call.
+	if !phonePattern.MatchString(s) || datePattern.MatchString(s) || dottedQuad.MatchString(s) {
+		return false
+	}
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits < 7 || digits > 15 {
+		return false
+	}
+	return strings.HasPrefix(s, "+") || strings.ContainsAny(s, " ()-.")
+}
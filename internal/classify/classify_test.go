@@ -0,0 +1,73 @@
+package classify
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"https://example.com/a?b=1", "url"},
+		{"www.example.com", "url"},
+		{"ssh://git@example.com/repo", "url"},
+		{"example.com", "text"},
+		{"user@example.com", "email"},
+		{"mailto:user@example.com", "email"},
+		{"user@localhost", "text"},
+		{"123e4567-e89b-12d3-a456-426614174000", "uuid"},
+		{"#fff", "color"},
+		{"#1e90ffcc", "color"},
+		{"rgb(30, 144, 255)", "color"},
+		{"hsla(210 100% 50% / 0.5)", "color"},
+		{"#123456789", "text"},
+		{"/etc/hosts", "path"},
+		{"~/notes/todo.md", "path"},
+		{"../src/main.go", "path"},
+		{"file:///tmp/a.txt", "path"},
+		{"/", "path"},
+		{"42", "number"},
+		{"-3.14", "number"},
+		{"1,234,567.89", "number"},
+		{"6.02e23", "number"},
+		{"+55 11 91234-5678", "phone"},
+		{"(555) 123-4567", "phone"},
+		{"555.123.4567", "phone"},
+		{"192.168.0.1", "text"},
+		{"10.0.0.255", "text"},
+		{"2024-05-31", "text"},
+		{"31/05/2024", "text"},
+		{"123-45", "text"},
+		{`{"a": [1, 2]}`, "json"},
+		{"[1, 2, 3]", "json"},
+		{"{not json}", "text"},
+		{"package main\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}", "code/go"},
+		{"def f(x):\n    return x\n\nprint(f(1))", "code/python"},
+		{"const x = () => 1;\nconsole.log(x());", "code/javascript"},
+		{"#!/bin/bash\nif [ -n \"$HOME\" ]; then\n  echo ok && ls\nfi", "code/shell"},
+		{"SELECT id FROM users WHERE name = 'a';", "code/sql"},
+		{"fn main() {\n    let mut x = 1;\n    println!(\"{}\", x);\n}", "code/rust"},
+		{"public class A {\n    @Override\n    public void run() { System.out.println(1); }\n}", "code/java"},
+		{"#include <stdio.h>\nint main() { printf(\"hi\"); }", "code/c"},
+		{"<!doctype html>\n<html><body><p>hi</p></body></html>", "code/html"},
+		{".a > .b {\n  color: red;\n}", "code/css"},
+		{"Just a sentence, nothing more (really).", "text"},
+		{"   ", "text"},
+		{"https://example.com\nsecond line", "text"},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.text).Label(); got != tt.want {
+			t.Errorf("Classify(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	for _, k := range Kinds {
+		if got, ok := ParseKind(" " + string(k) + " "); !ok || got != k {
+			t.Errorf("ParseKind(%q) = %q, %v", k, got, ok)
+		}
+	}
+	if _, ok := ParseKind("image"); ok {
+		t.Error("ParseKind(image) succeeded")
+	}
+}
@@ -0,0 +1,69 @@
+package classify
+
+import (
+	"regexp"
+	"strings"
+)
+
+type languageRule struct {
+	name    string
+	markers []*regexp.Regexp
+}
+
+// languageRules are checked with every marker worth one point; the best
+// scoring language with at least two points wins.
+var languageRules = []languageRule{
+	{"go", compileAll(`(?m)^package \w+$`, `\bfunc (\(\w+ \*?\w+\) )?\w+\(`, `:=`, `(?m)^import \(`, `\bfmt\.\w+\(`, `\berr != nil\b`)},
+	{"python", compileAll(`(?m)^\s*def \w+\(.*\):`, `(?m)^\s*(from \w+(\.\w+)* )?import \w+`, `\bself\.\w+`, `(?m)^\s*class \w+(\(.*\))?:`, `\bprint\(`, `(?m)^\s*(elif|except)\b.*:`)},
+	{"javascript", compileAll(`\bfunction\s*\w*\(`, `\b(const|let|var) \w+ =`, `=>`, `\bconsole\.\w+\(`, `\brequire\(`, `(?m)^\s*(export|import) .*from ['"]`)},
+	{"shell", compileAll(`^#!.*\b(ba|z)?sh\b`, `(?m)^\s*(sudo|apt|apt-get|cd|ls|echo|export|git|docker|kubectl|curl) `, `\$\{?\w+\}?`, `\s(\|\||&&|\|)\s`, `(?m)^\s*(if|fi|then|done|esac)\b`)},
+	{"sql", compileAll(`(?i)\bselect\b.+\bfrom\b`, `(?i)\binsert into\b`, `(?i)\bupdate \w+ set\b`, `(?i)\b(where|group by|order by|inner join|left join)\b`, `(?i)\bcreate (table|index)\b`)},
+	{"rust", compileAll(`\bfn \w+\(`, `\blet mut\b`, `\bimpl\b`, `(?m)^\s*use \w+(::\w+)+;`, `\w+!\(`, `->\s*\w+`)},
+	{"java", compileAll(`\bpublic (static )?(class|void|interface)\b`, `\bSystem\.out\.`, `(?m)^\s*import java\.`, `\bprivate \w+ \w+;`, `@Override`)},
+	{"c", compileAll(`(?m)^#include [<"]`, `\bint main\(`, `\bprintf\(`, `\bmalloc\(`, `(?m)^#define \w+`)},
+	{"html", compileAll(`(?i)<!doctype html`, `(?i)</?(html|head|body|div|span|p|a|script|style)\b[^>]*>`, `(?i)<\w+( \w+="[^"]*")+\s*/?>`)},
+	{"css", compileAll(`(?m)^\s*[.#]?[\w-]+(\s*[,>+~]?\s*[.#]?[\w-]+)*\s*\{`, `(?m)^\s*[\w-]+\s*:\s*[^;]+;\s*$`, `@media\b`)},
+}
+
+func compileAll(patterns ...string) []*regexp.Regexp {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		out = append(out, regexp.MustCompile(p))
+	}
+	return out
+}
+
+func guessLanguage(s string) string {
+	if !looksLikeCode(s) {
+		return ""
+	}
+	best, bestScore := "", 1
+	for _, rule := range languageRules {
+		score := 0
+		for _, re := range rule.markers {
+			if re.MatchString(s) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = rule.name, score
+		}
+	}
+	return best
+}
+
+// looksLikeCode rejects prose before running the language rules.
+func looksLikeCode(s string) bool {
+	symbols := 0
+	for _, r := range s {
+		switch r {
+		case '{', '}', '(', ')', ';', '=', '<', '>', '$', '|', '&', '[', ']':
+			symbols++
+		}
+	}
+	if symbols == 0 {
+		return false
+	}
+	words := len(strings.Fields(s))
+	return symbols*8 >= words || strings.Contains(s, "\n    ") || strings.Contains(s, "\n\t")
+}
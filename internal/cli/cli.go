@@ -3,6 +3,7 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,38 +12,192 @@ import (
 	"syscall"
 	"time"
 
+	"stashclip/internal/actions"
+	"stashclip/internal/classify"
 	"stashclip/internal/clipboard"
+	"stashclip/internal/config"
 	"stashclip/internal/daemon"
+	"stashclip/internal/dbusapi"
+	"stashclip/internal/hooks"
+	"stashclip/internal/i18n"
+	"stashclip/internal/logging"
+	"stashclip/internal/notify"
 	"stashclip/internal/popup"
 	"stashclip/internal/store"
+	"stashclip/internal/tags"
+	"stashclip/internal/transform"
+	"stashclip/internal/tray"
+)
+
+var (
+	logger   = logging.Discard()
+	notifier *notify.Notifier
+	// dedupe is the dedupe mode of the config, applied to every store.
+	dedupe = store.DedupeConsecutive
 )
 
 // Run executes the CLI command based on args.
 func Run(args []string) error {
+	args, err := parseGlobalFlags(args)
+	if err != nil {
+		return err
+	}
+	applyConfig()
+	defer notifier.Close()
+	defer notifier.Wait()
 	if len(args) < 2 {
-		return runPopup()
+		return runPopup(nil)
 	}
 
 	switch args[1] {
 	case "menu":
-		return runPopup()
+		return runPopup(args[2:])
 	case "popup":
-		return runPopup()
+		return runPopup(args[2:])
+	case "list":
+		return runList(args[2:])
+	case "pick":
+		return runPick(args[2:])
+	case "search":
+		return runSearch(args[2:])
+	case "transform":
+		return runTransform(args[2:])
+	case "action":
+		return runAction(args[2:])
+	case "tui":
+		return runTUI(args[2:])
+	case "set":
+		return runSet(args[2:])
+	case "get":
+		return runGet(args[2:])
+	case "registers":
+		return runRegisters(args[2:])
+	case "push":
+		return runPush(args[2:])
+	case "pop":
+		return runPop(args[2:])
+	case "queue":
+		return runQueue(args[2:])
+	case "snippet":
+		return runSnippet(args[2:])
+	case "tag":
+		return runTag(args[2:])
+	case "tags":
+		return runTags(args[2:])
+	case "store":
+		return runStoreCommand(args[2:])
+	case "export":
+		return runExport(args[2:])
+	case "import":
+		return runImport(args[2:])
 	case "__daemon-run":
 		return runDaemonForeground()
 	case "-h", "--help", "help":
 		usage()
 		return nil
 	default:
-		return fmt.Errorf("unsupported command: use Ctrl+Alt+A to open stashclip popup")
+		return errors.New(i18n.T("cli.unsupported"))
 	}
 }
 
 func usage() {
-	fmt.Println("Usage: stashclip [popup]")
+	fmt.Println("Usage: stashclip [flags] [popup] [--kind KIND] [--tag TAG] [--paste|--no-paste] [--snippets]")
+	fmt.Println("       stashclip [flags] list [--kind KIND] [--tag TAG]")
+	fmt.Println("       stashclip [flags] search <query> [--kind KIND] [--tag TAG]")
+	fmt.Println("       stashclip [flags] pick [id...] [--sep SEP] [--save]")
+	fmt.Println("       stashclip [flags] transform [<name> [id] [--save]]")
+	fmt.Println("       stashclip [flags] action <id> [action]")
+	fmt.Println("       stashclip [flags] tui [--kind KIND] [--tag TAG] [--print]")
+	fmt.Println("       stashclip [flags] set <name> [text...|--from-clipboard|--id N]")
+	fmt.Println("       stashclip [flags] get <name> [--print]")
+	fmt.Println("       stashclip [flags] registers")
+	fmt.Println("       stashclip [flags] push|pop")
+	fmt.Println("       stashclip [flags] queue <id...>|--stop")
+	fmt.Println("       stashclip [flags] snippet [<name> [--print]]")
+	fmt.Println("       stashclip [flags] tag <id> [tag,-tag...]")
+	fmt.Println("       stashclip [flags] tags")
+	fmt.Println("       stashclip [flags] export [--format FORMAT] [--kind KIND] [--tag TAG] [FILE]")
+	fmt.Println("       stashclip [flags] import [--format FORMAT] [--dry-run] [--no-dedupe] FILE|-")
+	fmt.Println("       stashclip [flags] store check|repair|restore [BACKUP]")
 	fmt.Println()
-	fmt.Println("Open popup and choose an item to copy again.")
-	fmt.Println("Recommended usage: global shortcut Ctrl+Alt+A -> stashclip-popup")
+	fmt.Println(i18n.T("cli.usage", kindNames()))
+}
+
+// applyConfig sets up the message catalog and notifications. The config
+// "locale" overrides the one detected from LANG and LC_MESSAGES. Config
+// errors are reported by the command that loads it.
+func applyConfig() {
+	cfg, err := config.Load()
+	if err != nil {
+		return
+	}
+	notifier = notify.New(cfg.Notifications, logger.With("component", "notify"))
+	if mode, ok := store.ParseDedupe(cfg.History.Dedupe); ok {
+		dedupe = mode
+	} else {
+		logger.Warn("unknown dedupe mode in config", "dedupe", cfg.History.Dedupe)
+	}
+	if cfg.Locale == "" {
+		return
+	}
+	if !i18n.SetLocale(cfg.Locale) {
+		logger.Warn("unknown locale in config", "locale", cfg.Locale, "available", i18n.Locales())
+	}
+}
+
+// parseGlobalFlags removes the logging flags given before the command name
+// and configures the loggers of every package accordingly. Arguments from
+// the command name, or after "--", are left to the command.
+func parseGlobalFlags(args []string) ([]string, error) {
+	opts, err := logging.DefaultOptions()
+	if err != nil {
+		return nil, err
+	}
+	rest := make([]string, 0, len(args))
+	global := true
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if i == 0 || !global {
+			rest = append(rest, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--":
+			global = false
+		case "-v", "--verbose":
+			opts.Level = slog.LevelDebug
+		case "--log-contents":
+			opts.ShowContents = true
+		case "--log-level", "--log-format":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return nil, errorf("err.value", name)
+				}
+				i++
+				value = args[i]
+			}
+			if name == "--log-level" {
+				level, err := logging.ParseLevel(value)
+				if err != nil {
+					return nil, err
+				}
+				opts.Level = level
+			} else {
+				format, err := logging.ParseFormat(value)
+				if err != nil {
+					return nil, err
+				}
+				opts.Format = format
+			}
+		default:
+			global = false
+			rest = append(rest, arg)
+		}
+	}
+	logger = logging.New(opts)
+	clipboard.SetLogger(logger.With("component", "clipboard"))
+	return rest, nil
 }
 
 func runDaemonCommand(args []string) error {
@@ -50,7 +205,7 @@ func runDaemonCommand(args []string) error {
 		return startDaemon()
 	}
 	if len(args) != 1 {
-		return fmt.Errorf("daemon error: invalid arguments")
+		return failf("daemon", "err.daemon.args")
 	}
 
 	switch args[0] {
@@ -61,21 +216,66 @@ func runDaemonCommand(args []string) error {
 	case "status":
 		return daemonStatus()
 	default:
-		return fmt.Errorf("daemon error: unknown action: %s", args[0])
+		return failf("daemon", "err.action", args[0])
 	}
 }
 
 func runDaemonForeground() error {
 	clipboardProvider, err := clipboard.NewProvider()
 	if err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
-	memStore, err := newStore()
+	memStore, err := openStore()
 	if err != nil {
 		return err
 	}
-	if err := daemon.Run(clipboardProvider, memStore); err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := store.ParseDedupe(cfg.History.Dedupe); !ok {
+		return failf("config", "err.dedupe", cfg.History.Dedupe)
+	}
+	hookRunner, err := hooks.NewRunner(cfg.Hooks, logger.With("component", "hooks"))
+	if err != nil {
+		return fail("config", err)
+	}
+	tagRules, err := tags.NewRules(cfg.TagRules)
+	if err != nil {
+		return fail("config", err)
+	}
+	memStore.SetTagRules(tagRules)
+	service, err := dbusapi.Start(memStore, clipboardProvider, dbusapi.Options{
+		Logger:   logger.With("component", "dbus"),
+		Notifier: notifier,
+	})
+	if err != nil {
+		logger.Warn("running without dbus service", "error", err)
+	}
+	defer service.Close()
+	var trayIcon *tray.Tray
+	if cfg.Tray.Enabled && service != nil {
+		trayIcon, err = tray.Start(memStore, clipboardProvider, tray.Options{
+			Logger:     logger.With("component", "tray"),
+			Entries:    cfg.Tray.Entries,
+			Controller: service,
+		})
+		if err != nil {
+			logger.Warn("running without tray", "error", err)
+		} else {
+			service.OnPauseChanged(trayIcon.PauseChanged)
+		}
+	}
+	defer trayIcon.Close()
+	opts := daemon.Options{
+		Logger:   logger.With("component", "daemon"),
+		Hooks:    hookRunner,
+		Notifier: notifier,
+		Service:  service,
+		Tray:     trayIcon,
+	}
+	if err := daemon.Run(clipboardProvider, memStore, opts); err != nil {
+		return fail("daemon", err)
 	}
 	return nil
 }
@@ -84,10 +284,10 @@ func startDaemon() error {
 	pidPath := daemonPIDPath()
 	pid, running, err := readDaemonPID(pidPath)
 	if err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
 	if running {
-		fmt.Printf("daemon already running (pid %d)\n", pid)
+		fmt.Println(i18n.T("cli.daemon.already", pid))
 		return nil
 	}
 	if pid != 0 {
@@ -96,11 +296,11 @@ func startDaemon() error {
 
 	exe, err := os.Executable()
 	if err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
 	logFile, err := openDaemonLog()
 	if err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
 	defer logFile.Close()
 
@@ -111,17 +311,17 @@ func startDaemon() error {
 	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 
 	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
 	if err := writeDaemonPID(pidPath, cmd.Process.Pid); err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
 	time.Sleep(700 * time.Millisecond)
 	if !processRunning(cmd.Process.Pid) {
 		_ = os.Remove(pidPath)
-		return fmt.Errorf("daemon error: failed to stay running (check %s)", daemonLogPath())
+		return failf("daemon", "err.daemon.start", daemonLogPath())
 	}
-	fmt.Printf("daemon started (pid %d)\n", cmd.Process.Pid)
+	fmt.Println(i18n.T("cli.daemon.started", cmd.Process.Pid))
 	return nil
 }
 
@@ -129,154 +329,478 @@ func stopDaemon() error {
 	pidPath := daemonPIDPath()
 	pid, running, err := readDaemonPID(pidPath)
 	if err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
 	if !running {
 		_ = os.Remove(pidPath)
-		return fmt.Errorf("daemon error: not running")
+		return failf("daemon", "err.daemon.idle")
 	}
 
 	proc, err := os.FindProcess(pid)
 	if err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
 	if err := proc.Signal(syscall.SIGTERM); err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
 	if waitProcessStop(pid, 3*time.Second) {
 		_ = os.Remove(pidPath)
-		fmt.Printf("daemon stopped (pid %d)\n", pid)
+		fmt.Println(i18n.T("cli.daemon.stopped", pid))
 		return nil
 	}
 
 	if err := proc.Signal(syscall.SIGKILL); err != nil {
-		return fmt.Errorf("daemon error: failed to stop pid %d after timeout: %w", pid, err)
+		return failf("daemon", "err.daemon.timeout", pid, err)
 	}
 	if waitProcessStop(pid, 1*time.Second) {
 		_ = os.Remove(pidPath)
-		fmt.Printf("daemon stopped (pid %d)\n", pid)
+		fmt.Println(i18n.T("cli.daemon.stopped", pid))
 		return nil
 	}
 
-	return fmt.Errorf("daemon error: process %d did not stop", pid)
+	return failf("daemon", "err.daemon.stop", pid)
 }
 
 func daemonStatus() error {
 	pid, running, err := readDaemonPID(daemonPIDPath())
 	if err != nil {
-		return fmt.Errorf("daemon error: %w", err)
+		return fail("daemon", err)
 	}
 	if running {
-		fmt.Printf("daemon running (pid %d)\n", pid)
+		fmt.Println(i18n.T("cli.daemon.running", pid))
 	} else {
-		fmt.Println("daemon not running")
+		fmt.Println(i18n.T("cli.daemon.idle"))
 	}
 	return nil
 }
 
-func runList() error {
+func runList(args []string) error {
+	filter, rest, err := parseFilterFlags(args)
+	if err != nil {
+		return fail("list", err)
+	}
+	if len(rest) > 0 {
+		return failf("list", "err.args")
+	}
 	memStore, err := newStore()
 	if err != nil {
 		return err
 	}
-	entries := memStore.List()
+	printEntries(memStore.List(), filter, "")
+	return nil
+}
+
+func runSearch(args []string) error {
+	filter, rest, err := parseFilterFlags(args)
+	if err != nil {
+		return fail("search", err)
+	}
+	if len(rest) == 0 {
+		return failf("search", "err.query")
+	}
+	memStore, err := newStore()
+	if err != nil {
+		return err
+	}
+	printEntries(memStore.List(), filter, strings.Join(rest, " "))
+	return nil
+}
+
+// printEntries prints entries matching filter and query with their 1-based index.
+func printEntries(entries []store.Entry, filter entryFilter, query string) {
 	for i, entry := range entries {
+		if !entryMatches(entry, filter, query) {
+			continue
+		}
 		text := strings.ReplaceAll(entry.Text, "\n", "\\n")
 		text = strings.ReplaceAll(text, "\t", "\\t")
-		fmt.Printf("%d\t%s\t%s\n", i+1, entry.AddedAt.Format(time.RFC3339), text)
+		fmt.Printf("%d\t%s\t%s\t%s\n", i+1, entry.AddedAt.Format(time.RFC3339), entryBadge(entry), text)
 	}
-	return nil
+}
+
+// entryBadge is the content kind of entry followed by its tags.
+func entryBadge(entry store.Entry) string {
+	badge := entry.Classification().Label()
+	if len(entry.Tags) > 0 {
+		badge += " " + tags.Badge(entry.Tags)
+	}
+	return badge
+}
+
+// entryFilter selects entries by content kind and tag; empty fields match
+// anything.
+type entryFilter struct {
+	kind classify.Kind
+	tag  string
+}
+
+// entryMatches reports whether entry passes filter and contains query
+// case-insensitively (if set).
+func entryMatches(entry store.Entry, filter entryFilter, query string) bool {
+	if filter.kind != "" && entry.Kind != filter.kind {
+		return false
+	}
+	if filter.tag != "" && !tags.Has(entry.Tags, filter.tag) {
+		return false
+	}
+	if query != "" && !strings.Contains(strings.ToLower(entry.Text), strings.ToLower(query)) {
+		return false
+	}
+	return true
+}
+
+// parseFilterFlags extracts --kind KIND and --tag TAG (or --kind=KIND and
+// --tag=TAG) from args.
+func parseFilterFlags(args []string) (entryFilter, []string, error) {
+	var filter entryFilter
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--kind" && name != "--tag" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return entryFilter{}, nil, errorf("err.value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "--tag" {
+			parsed := tags.Parse(value)
+			if len(parsed) != 1 {
+				return entryFilter{}, nil, errorf("err.tag", value)
+			}
+			filter.tag = parsed[0]
+			continue
+		}
+		k, ok := classify.ParseKind(value)
+		if !ok {
+			return entryFilter{}, nil, errorf("err.kind", value, kindNames())
+		}
+		filter.kind = k
+	}
+	return filter, rest, nil
+}
+
+// transformLabel is the translated description of a transform.
+func transformLabel(t transform.Transform) string {
+	if label, ok := i18n.Lookup("transform." + t.Name); ok {
+		return label
+	}
+	return t.Description
+}
+
+func kindNames() string {
+	names := make([]string, 0, len(classify.Kinds))
+	for _, k := range classify.Kinds {
+		names = append(names, string(k))
+	}
+	return strings.Join(names, ", ")
 }
 
 func runPick(args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	sep, save := cfg.Merge.Separator, cfg.Merge.Save
+	var ids []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		switch name {
+		case "--save":
+			save = true
+		case "--sep":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return failf("pick", "err.value", "--sep")
+				}
+				i++
+				value = args[i]
+			}
+			sep = value
+		default:
+			ids = append(ids, args[i])
+		}
+	}
+
 	memStore, err := newStore()
 	if err != nil {
 		return err
 	}
 	entries := memStore.List()
 	if len(entries) == 0 {
-		return fmt.Errorf("pick error: no entries available")
+		return failf("pick", "err.empty")
 	}
-
-	selected := len(entries)
-	if len(args) > 1 {
-		return fmt.Errorf("pick error: too many arguments")
+	if len(ids) == 0 {
+		return writePickByIndex(memStore, entries, len(entries))
 	}
-	if len(args) == 1 {
-		n, convErr := strconv.Atoi(args[0])
+	selected := make([]int, 0, len(ids))
+	for _, arg := range ids {
+		n, convErr := strconv.Atoi(arg)
 		if convErr != nil {
-			return fmt.Errorf("pick error: invalid index: %s", args[0])
+			return failf("pick", "err.index", arg)
 		}
-		selected = n
+		selected = append(selected, n)
 	}
-	return writePickByIndex(entries, selected)
+	if len(selected) == 1 {
+		return writePickByIndex(memStore, entries, selected[0])
+	}
+	merged, err := mergeByIndex(entries, selected, sep)
+	if err != nil {
+		return err
+	}
+	if save {
+		if err := memStore.Add(merged); err != nil {
+			return fail("store", err)
+		}
+	}
+	return writePickText(merged)
 }
 
-func runPopup() error {
-	for {
-		memStore, err := newStore()
-		if err != nil {
-			return err
+// mergeByIndex joins the entries at the 1-based indexes, in the given order,
+// dropping trailing newlines of each part.
+func mergeByIndex(entries []store.Entry, oneBasedIndexes []int, sep string) (string, error) {
+	parts := make([]string, 0, len(oneBasedIndexes))
+	for _, n := range oneBasedIndexes {
+		if n < 1 || n > len(entries) {
+			return "", failf("pick", "err.range", n)
 		}
-		entries := memStore.List()
-		if len(entries) == 0 {
-			return fmt.Errorf("popup error: no entries available")
+		parts = append(parts, strings.TrimRight(entries[n-1].Text, "\r\n"))
+	}
+	return strings.Join(parts, mergeSeparator(sep)), nil
+}
+
+func mergeSeparator(name string) string {
+	switch name {
+	case "", "newline":
+		return "\n"
+	case "space":
+		return " "
+	case "comma":
+		return ","
+	case "tab":
+		return "\t"
+	default:
+		return strings.NewReplacer(`\n`, "\n", `\t`, "\t").Replace(name)
+	}
+}
+
+func runTransform(args []string) error {
+	save := false
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--save" {
+			save = true
+			continue
 		}
-		items := make([]popup.Item, 0, len(entries))
-		for i, entry := range entries {
-			items = append(items, popup.Item{
-				ID:      i + 1,
-				AddedAt: entry.AddedAt,
-				Text:    entry.Text,
-			})
+		rest = append(rest, arg)
+	}
+	if len(rest) == 0 {
+		for _, t := range transform.List() {
+			fmt.Printf("%s\t%s\n", t.Name, transformLabel(t))
 		}
-		selected, err := popup.Select(items)
+		return nil
+	}
+	if len(rest) > 2 {
+		return failf("transform", "err.args")
+	}
+	if _, ok := transform.Lookup(rest[0]); !ok {
+		return failf("transform", "err.transform", rest[0])
+	}
+
+	memStore, err := newStore()
+	if err != nil {
+		return err
+	}
+	entries := memStore.List()
+	if len(entries) == 0 {
+		return failf("transform", "err.empty")
+	}
+	selected := len(entries)
+	if len(rest) == 2 {
+		n, convErr := strconv.Atoi(rest[1])
+		if convErr != nil {
+			return failf("transform", "err.index", rest[1])
+		}
+		selected = n
+	}
+	if selected < 1 || selected > len(entries) {
+		return failf("transform", "err.range", selected)
+	}
+	if !save {
+		cfg, err := loadConfig()
 		if err != nil {
-			if errors.Is(err, popup.ErrCanceled) {
-				return nil
-			}
 			return err
 		}
-		if err := writePickByIndex(entries, selected); err != nil {
-			return err
+		save = cfg.Transform.Save
+	}
+	return writeTransformed(memStore, entries[selected-1].Text, rest[0], save)
+}
+
+func runAction(args []string) error {
+	if len(args) == 0 {
+		return failf("action", "err.id")
+	}
+	if len(args) > 2 {
+		return failf("action", "err.args")
+	}
+	memStore, err := newStore()
+	if err != nil {
+		return err
+	}
+	entries := memStore.List()
+	selected, err := strconv.Atoi(args[0])
+	if err != nil {
+		return failf("action", "err.index", args[0])
+	}
+	if selected < 1 || selected > len(entries) {
+		return failf("action", "err.range", selected)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	entry := entries[selected-1]
+	if len(args) == 1 {
+		for _, a := range actions.For(entry.Kind, cfg.Actions) {
+			fmt.Printf("%s\t%s\n", a.Name, a.Label)
+		}
+		return nil
+	}
+	return runEntryAction(entry, selected, args[1], cfg)
+}
+
+func runEntryAction(entry store.Entry, oneBasedIndex int, name string, cfg config.Config) error {
+	if err := actions.Validate(cfg.Actions); err != nil {
+		return fail("config", err)
+	}
+	target := actions.Target{ID: oneBasedIndex, Text: entry.Text, Kind: entry.Kind}
+	if err := actions.Run(name, target, cfg.Actions); err != nil {
+		return fail("action", err)
+	}
+	return nil
+}
+
+// writeTransformed applies the named transform, copies the result and
+// optionally stores it as a new entry.
+func writeTransformed(memStore *store.Store, text, name string, save bool) error {
+	out, err := transform.Apply(name, text)
+	if err != nil {
+		return fail("transform", err)
+	}
+	clipboardProvider, err := clipboard.NewProvider()
+	if err != nil {
+		return fail("transform", err)
+	}
+	if err := clipboard.MarkIgnored(out); err != nil {
+		return fail("transform", err)
+	}
+	if err := clipboardProvider.Write(out); err != nil {
+		return fail("transform", err)
+	}
+	if save {
+		if err := memStore.Add(out); err != nil {
+			return fail("store", err)
 		}
 	}
+	return nil
 }
 
-func writePickByIndex(entries []store.Entry, oneBasedIndex int) error {
+// writePickByIndex copies an entry and counts it as used.
+func writePickByIndex(memStore *store.Store, entries []store.Entry, oneBasedIndex int) error {
 	if oneBasedIndex < 1 || oneBasedIndex > len(entries) {
-		return fmt.Errorf("pick error: index out of range: %d", oneBasedIndex)
+		return failf("pick", "err.range", oneBasedIndex)
 	}
+	if err := writePickText(entries[oneBasedIndex-1].Text); err != nil {
+		return err
+	}
+	return touchEntry(memStore, entries[oneBasedIndex-1])
+}
+
+// touchEntry counts an entry listed earlier as used. The store is
+// reloaded first so that entries captured since are kept; an entry deleted
+// since is left alone.
+func touchEntry(memStore *store.Store, entry store.Entry) error {
+	if err := memStore.Reload(); err != nil {
+		return fail("store", err)
+	}
+	if err := memStore.TouchEntry(entry); err != nil && !errors.Is(err, store.ErrGone) {
+		return fail("store", err)
+	}
+	return nil
+}
+
+// writePickText copies text and marks it so the daemon does not store it again.
+func writePickText(text string) error {
 	clipboardProvider, err := clipboard.NewProvider()
 	if err != nil {
-		return fmt.Errorf("pick error: %w", err)
+		return fail("pick", err)
 	}
-	if err := clipboard.MarkIgnored(entries[oneBasedIndex-1].Text); err != nil {
-		return fmt.Errorf("pick error: %w", err)
+	if err := clipboard.MarkIgnored(text); err != nil {
+		return fail("pick", err)
 	}
-	if err := clipboardProvider.Write(entries[oneBasedIndex-1].Text); err != nil {
-		return fmt.Errorf("pick error: %w", err)
+	if err := clipboardProvider.Write(text); err != nil {
+		return fail("pick", err)
 	}
+	notifyPicked(text)
 	return nil
 }
 
+// notifyPicked announces a copied text with its summary.
+func notifyPicked(text string) {
+	notifier.Send(notify.EventPick, i18n.T("notify.pick"), popup.Summary(text, 100))
+}
+
 func runClear() error {
 	memStore, err := newStore()
 	if err != nil {
 		return err
 	}
-	memStore.Clear()
+	if err := memStore.Clear(); err != nil {
+		return fail("store", err)
+	}
 	return nil
 }
 
+// newStore opens the history for a command, which tells the daemon when it
+// removes entries so that D-Bus clients see the removal.
 func newStore() (*store.Store, error) {
+	memStore, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	memStore.SetOnRemove(func([]int) {
+		if err := dbusapi.Call("Reload"); err != nil && !errors.Is(err, dbusapi.ErrNotRunning) {
+			logger.Debug("cannot tell daemon about removed entries", "error", err)
+		}
+	})
+	return memStore, nil
+}
+
+func openStore() (*store.Store, error) {
 	memStore, err := store.New()
 	if err != nil {
-		return nil, fmt.Errorf("store error: %w", err)
+		return nil, fail("store", err)
+	}
+	memStore.SetLogger(logger.With("component", "store"))
+	memStore.SetDedupe(dedupe)
+	if quarantine := memStore.Recovered(); quarantine != "" {
+		fmt.Fprintln(os.Stderr, i18n.T("cli.store.recovered", memStore.Len(), quarantine))
 	}
 	return memStore, nil
 }
 
+func loadConfig() (config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return cfg, fail("config", err)
+	}
+	return cfg, nil
+}
+
 func daemonPIDPath() string {
 	return filepath.Join(daemonStateDir(), "daemon.pid")
 }
@@ -311,7 +835,7 @@ func readDaemonPID(path string) (int, bool, error) {
 	}
 	pid, err := strconv.Atoi(s)
 	if err != nil || pid <= 0 {
-		return 0, false, fmt.Errorf("invalid pid file")
+		return 0, false, errorf("err.pid")
 	}
 	return pid, processRunning(pid), nil
 }
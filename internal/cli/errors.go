@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"fmt"
+
+	"stashclip/internal/i18n"
+)
+
+// errorf returns the catalog message for key formatted with args; a %w
+// verb wraps its argument as in fmt.Errorf.
+func errorf(key string, args ...any) error {
+	return fmt.Errorf(i18n.T(key), args...)
+}
+
+// failf is errorf prefixed with the area of the failure, usually the
+// command, such as "pick error: index out of range: 3".
+func failf(area, key string, args ...any) error {
+	return fmt.Errorf(i18n.T("err.prefix", area)+i18n.T(key), args...)
+}
+
+// fail wraps err with the prefix of the area.
+func fail(area string, err error) error {
+	return fmt.Errorf(i18n.T("err.prefix", area)+"%w", err)
+}
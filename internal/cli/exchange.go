@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"stashclip/internal/exchange"
+	"stashclip/internal/i18n"
+	"stashclip/internal/store"
+)
+
+// runExport writes the history, or the entries matching --kind and --tag,
+// to a file or to stdout.
+func runExport(args []string) error {
+	filter, rest, err := parseFilterFlags(args)
+	if err != nil {
+		return fail("export", err)
+	}
+	format, rest, err := parseFormatFlag(rest)
+	if err != nil {
+		return fail("export", err)
+	}
+	if len(rest) > 1 {
+		return failf("export", "err.args")
+	}
+	path := "-"
+	if len(rest) == 1 {
+		path = rest[0]
+	}
+	if format == "" {
+		format = exchange.JSONL
+		if path != "-" {
+			guessed, ok := exchange.FormatFor(path)
+			if !ok {
+				return failf("export", "err.format.guess", path)
+			}
+			format = guessed
+		}
+	}
+	if !exportable(format) {
+		return failf("export", "err.format.export", format, formatNames(exchange.ExportFormats))
+	}
+
+	memStore, err := newStore()
+	if err != nil {
+		return err
+	}
+	var entries []store.Entry
+	for _, entry := range memStore.List() {
+		if entryMatches(entry, filter, "") {
+			entries = append(entries, entry)
+		}
+	}
+
+	if path == "-" {
+		if err := exchange.Export(os.Stdout, format, entries); err != nil {
+			return fail("export", err)
+		}
+		return nil
+	}
+	if err := writeExport(path, format, entries); err != nil {
+		return fail("export", err)
+	}
+	return nil
+}
+
+// writeExport writes to a temp file renamed over path, gzipping archives
+// named .tar.gz or .tgz.
+func writeExport(path string, format exchange.Format, entries []store.Entry) error {
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if format == exchange.Archive && exchange.Compressed(path) {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+	err = exchange.Export(w, format, entries)
+	if err == nil && zw != nil {
+		err = zw.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// runImport adds the entries of an export or of another clipboard
+// manager's history. Texts already in the history are skipped unless
+// --no-dedupe is given, which in the global dedupe mode counts them as uses
+// of the entries instead; --dry-run only reports what would change.
+func runImport(args []string) error {
+	format, rest, err := parseFormatFlag(args)
+	if err != nil {
+		return fail("import", err)
+	}
+	dryRun, dedupe := false, true
+	var paths []string
+	for _, arg := range rest {
+		switch arg {
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--no-dedupe":
+			dedupe = false
+		default:
+			paths = append(paths, arg)
+		}
+	}
+	if len(paths) != 1 {
+		return failf("import", "err.import.file")
+	}
+	path := paths[0]
+	if format == "" {
+		guessed, ok := exchange.FormatFor(path)
+		if !ok {
+			return failf("import", "err.format.guess", path)
+		}
+		format = guessed
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fail("import", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	imported, shortened, err := exchange.Import(r, format)
+	if err != nil {
+		return failf("import", "err.import.read", format, err)
+	}
+	if shortened > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T("cli.import.short", shortened))
+	}
+
+	memStore, err := newStore()
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	if dedupe {
+		for _, entry := range memStore.List() {
+			seen[entry.Text] = true
+		}
+	}
+	var fresh []store.Entry
+	duplicates, empty := 0, 0
+	for _, entry := range imported {
+		switch {
+		case strings.TrimSpace(entry.Text) == "":
+			empty++
+		case seen[entry.Text]:
+			duplicates++
+		default:
+			if dedupe {
+				seen[entry.Text] = true
+			}
+			fresh = append(fresh, entry)
+		}
+	}
+
+	if dryRun {
+		fmt.Println(i18n.T("cli.import.dry", len(fresh), duplicates, empty))
+		return nil
+	}
+	before := memStore.Len()
+	if len(fresh) > 0 {
+		if err := memStore.Import(fresh); err != nil {
+			return fail("store", err)
+		}
+	}
+	fmt.Println(i18n.T("cli.import.done", len(fresh), duplicates, empty))
+	if dropped := before + len(fresh) - memStore.Len(); dropped > 0 {
+		fmt.Println(i18n.T("cli.import.trimmed", dropped))
+	}
+	return nil
+}
+
+// parseFormatFlag takes --format out of args.
+func parseFormatFlag(args []string) (exchange.Format, []string, error) {
+	var format exchange.Format
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--format" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", nil, errorf("err.value", "--format")
+			}
+			i++
+			value = args[i]
+		}
+		f, ok := exchange.ParseFormat(value)
+		if !ok {
+			return "", nil, errorf("err.format.unknown", value, formatNames(exchange.ImportFormats))
+		}
+		format = f
+	}
+	return format, rest, nil
+}
+
+func exportable(format exchange.Format) bool {
+	for _, f := range exchange.ExportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+func formatNames(formats []exchange.Format) string {
+	names := make([]string, 0, len(formats))
+	for _, f := range formats {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,505 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"stashclip/internal/actions"
+	"stashclip/internal/classify"
+	"stashclip/internal/clipboard"
+	"stashclip/internal/config"
+	"stashclip/internal/editor"
+	"stashclip/internal/i18n"
+	"stashclip/internal/notify"
+	"stashclip/internal/paste"
+	"stashclip/internal/popup"
+	"stashclip/internal/snippet"
+	"stashclip/internal/store"
+	"stashclip/internal/transform"
+	"stashclip/internal/tui"
+)
+
+// picker is the UI driving the pick flow: the popup backends or the terminal.
+type picker interface {
+	Select(items []popup.Item) (popup.Selection, error)
+	Choose(prompt string, options []popup.Option) (string, error)
+	// Ask reads a line of text, such as a snippet field.
+	Ask(prompt string) (string, error)
+	// Close releases the UI before a terminal action runs.
+	Close() error
+}
+
+type popupPicker struct{}
+
+func (popupPicker) Select(items []popup.Item) (popup.Selection, error) { return popup.Select(items) }
+func (popupPicker) Choose(prompt string, options []popup.Option) (string, error) {
+	return popup.Choose(prompt, options)
+}
+func (popupPicker) Ask(prompt string) (string, error) { return popup.Ask(prompt) }
+func (popupPicker) Close() error                      { return nil }
+
+type tuiPicker struct {
+	term *tui.Terminal
+}
+
+func (p tuiPicker) Select(items []popup.Item) (popup.Selection, error) {
+	return p.term.Select("Stashclip", items)
+}
+func (p tuiPicker) Choose(prompt string, options []popup.Option) (string, error) {
+	return p.term.Choose(prompt, options)
+}
+func (p tuiPicker) Ask(prompt string) (string, error) { return p.term.Ask(prompt) }
+func (p tuiPicker) Close() error                      { return p.term.Close() }
+
+// pickFlow shows the history until a terminal action runs. Deleting or
+// pinning reopens the list on the same position.
+type pickFlow struct {
+	ui     picker
+	cfg    config.Config
+	filter entryFilter
+	// deliver hands the chosen text to the user. capture is set when the
+	// text is new (edited) and the daemon may store it.
+	deliver func(text string, capture bool) error
+	// regs are the registers listed after the history, which has history
+	// entries.
+	regs    *store.Registers
+	history int
+}
+
+func runPopup(args []string) error {
+	filter, rest, err := parseFilterFlags(args)
+	if err != nil {
+		return fail("popup", err)
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	snippets := false
+	for _, arg := range rest {
+		switch arg {
+		case "--paste":
+			cfg.Paste.Enabled = true
+		case "--no-paste":
+			cfg.Paste.Enabled = false
+		case "--snippets":
+			snippets = true
+		default:
+			return failf("popup", "err.arg", arg)
+		}
+	}
+	if err := configurePopup(cfg); err != nil {
+		return err
+	}
+	flow := pickFlow{ui: popupPicker{}, cfg: cfg, filter: filter, deliver: copyText}
+	if cfg.Paste.Enabled {
+		paster, err := paste.New(cfg.Paste)
+		if err != nil {
+			return fail("config", err)
+		}
+		target := paster.Capture()
+		flow.deliver = func(text string, capture bool) error {
+			if err := copyText(text, capture); err != nil {
+				return err
+			}
+			return paster.Paste(target)
+		}
+	}
+	if snippets {
+		_, err := flow.chooseSnippet()
+		return err
+	}
+	return flow.run("popup")
+}
+
+func runTUI(args []string) error {
+	filter, rest, err := parseFilterFlags(args)
+	if err != nil {
+		return fail("tui", err)
+	}
+	printOnly := false
+	for _, arg := range rest {
+		if arg != "--print" {
+			return failf("tui", "err.arg", arg)
+		}
+		printOnly = true
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	term, err := tui.Open()
+	if err != nil {
+		return err
+	}
+	defer term.Close()
+	flow := pickFlow{ui: tuiPicker{term}, cfg: cfg, filter: filter, deliver: copyText}
+	if printOnly {
+		flow.deliver = func(text string, _ bool) error {
+			fmt.Print(text)
+			return nil
+		}
+	}
+	return flow.run("tui")
+}
+
+// configurePopup registers the configured popup backends and default provider.
+func configurePopup(cfg config.Config) error {
+	for _, bc := range cfg.Popup.Backends {
+		b, err := popup.NewCommandBackend(bc)
+		if err != nil {
+			return fail("config", err)
+		}
+		popup.Register(b)
+	}
+	popup.SetDefaultProvider(cfg.Popup.Provider)
+	return nil
+}
+
+func (f pickFlow) run(name string) error {
+	focus := 0
+	for {
+		memStore, err := newStore()
+		if err != nil {
+			return err
+		}
+		regs, err := newRegisters()
+		if err != nil {
+			return err
+		}
+		f.regs = regs
+		entries := memStore.List()
+		f.history = len(entries)
+		items := make([]popup.Item, 0, len(entries))
+		for i, entry := range entries {
+			if !entryMatches(entry, f.filter, "") {
+				continue
+			}
+			items = append(items, popup.Item{
+				ID:      i + 1,
+				AddedAt: entry.AddedAt,
+				Text:    entry.Text,
+				Kind:    entryBadge(entry),
+				Pinned:  entry.Pinned,
+				Uses:    entry.Uses,
+				Focus:   i+1 == focus,
+			})
+		}
+		// Registers follow the history as their own section, with the IDs
+		// after the last entry.
+		if f.filter == (entryFilter{}) {
+			for _, reg := range regs.List() {
+				class := classify.Classify(reg.Text)
+				entries = append(entries, store.Entry{Text: reg.Text, AddedAt: reg.UpdatedAt, Kind: class.Kind, Language: class.Language})
+				items = append(items, popup.Item{
+					ID:      len(entries),
+					AddedAt: reg.UpdatedAt,
+					Text:    reg.Text,
+					Kind:    registerBadge + reg.Name,
+					Focus:   len(entries) == focus,
+				})
+			}
+		}
+		if len(items) == 0 {
+			return failf(name, "err.empty")
+		}
+		selected, err := f.ui.Select(items)
+		if err != nil {
+			if errors.Is(err, popup.ErrCanceled) {
+				return nil
+			}
+			return err
+		}
+		if selected.Action == popup.ActionSnippets {
+			reopen, err := f.chooseSnippet()
+			if err != nil || !reopen {
+				return err
+			}
+			continue
+		}
+		if selected.ID < 1 || selected.ID > len(entries) {
+			return failf(name, "err.range", selected.ID)
+		}
+		if selected.Action == popup.ActionCopy && f.cfg.Popup.ActionStep {
+			selected.Action = popup.ActionMenu
+		}
+		reopen, err := f.perform(memStore, entries, selected)
+		if err != nil || !reopen {
+			return err
+		}
+		focus = selected.ID
+	}
+}
+
+// registerBadge prefixes the register name in the kind column.
+const registerBadge = "reg:"
+
+// register returns the name of the register shown with the 1-based id, if
+// the id is past the history entries.
+func (f pickFlow) register(id int) (string, bool) {
+	i := id - f.history - 1
+	regs := f.regs.List()
+	if i < 0 || i >= len(regs) {
+		return "", false
+	}
+	return regs[i].Name, true
+}
+
+// perform runs the action chosen for one or more entries and reports
+// whether the list should reopen. The entries are those listed when the
+// picker opened: the store is reloaded, to keep what the daemon captured
+// meanwhile, and changed by entry rather than by index, since entries may
+// have moved.
+func (f pickFlow) perform(memStore *store.Store, entries []store.Entry, sel popup.Selection) (bool, error) {
+	ids := sel.IDs
+	if len(ids) == 0 {
+		ids = []int{sel.ID}
+	}
+	for _, id := range ids {
+		if id < 1 || id > len(entries) {
+			return false, failf("pick", "err.range", id)
+		}
+	}
+	if err := memStore.Reload(); err != nil {
+		return false, fail("store", err)
+	}
+	switch sel.Action {
+	case popup.ActionDelete:
+		var deleted []int
+		sorted := append([]int(nil), ids...)
+		sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+		for _, id := range sorted {
+			if name, ok := f.register(id); ok {
+				if err := f.regs.Delete(name); err != nil {
+					return false, fail("store", err)
+				}
+				continue
+			}
+			if _, err := memStore.DeleteEntry(entries[id-1]); errors.Is(err, store.ErrGone) {
+				continue
+			} else if err != nil {
+				return false, fail("store", err)
+			}
+			deleted = append(deleted, id)
+		}
+		if len(deleted) > 0 {
+			notifyDeleted(entries, deleted)
+		}
+		return true, nil
+	case popup.ActionPin:
+		for _, id := range ids {
+			if _, ok := f.register(id); ok {
+				// Registers are never trimmed, so there is nothing to pin.
+				continue
+			}
+			if _, err := memStore.TogglePinEntry(entries[id-1]); err != nil && !errors.Is(err, store.ErrGone) {
+				return false, fail("store", err)
+			}
+		}
+		return true, nil
+	case popup.ActionMenu:
+		return f.chooseAction(memStore, entries, sel)
+	}
+
+	text, err := f.selectedText(entries, ids)
+	if err != nil {
+		return false, err
+	}
+	switch sel.Action {
+	case popup.ActionTransform:
+		return f.chooseTransform(memStore, text)
+	case popup.ActionEdit:
+		_ = f.ui.Close()
+		edited, err := editor.Edit(text)
+		if err != nil {
+			return false, fail("edit", err)
+		}
+		return false, f.deliver(edited, edited != text)
+	case popup.ActionCopyPlain:
+		_ = f.ui.Close()
+		if err := f.deliver(strings.TrimRight(text, "\r\n"), false); err != nil {
+			return false, err
+		}
+		return false, f.touch(memStore, entries, ids)
+	default:
+		_ = f.ui.Close()
+		// A merged text is stored as it was copied; transformed or edited
+		// ones follow their own settings.
+		if len(ids) > 1 && f.cfg.Merge.Save {
+			if err := memStore.Add(text); err != nil {
+				return false, fail("store", err)
+			}
+		}
+		if err := f.deliver(text, false); err != nil {
+			return false, err
+		}
+		return false, f.touch(memStore, entries, ids)
+	}
+}
+
+// touch counts a single picked history entry as used. Registers and merged
+// texts are not entries of their own.
+func (f pickFlow) touch(memStore *store.Store, entries []store.Entry, ids []int) error {
+	if len(ids) != 1 || ids[0] > f.history {
+		return nil
+	}
+	return touchEntry(memStore, entries[ids[0]-1])
+}
+
+// notifyDeleted announces deleted entries with an undo button, which puts
+// them back at their positions.
+func notifyDeleted(entries []store.Entry, ids []int) {
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+	summary, body := i18n.T("notify.delete"), popup.Summary(entries[sorted[0]-1].Text, 100)
+	if len(sorted) > 1 {
+		summary, body = i18n.T("notify.delete.many", len(sorted)), ""
+	}
+	undo := []notify.Action{{Key: "undo", Label: i18n.T("notify.undo")}}
+	notifier.SendWithActions(notify.EventDelete, summary, body, undo, func(string) {
+		memStore, err := newStore()
+		if err != nil {
+			logger.Warn("cannot undo delete", "error", err)
+			return
+		}
+		for _, id := range sorted {
+			if err := memStore.Insert(id-1, entries[id-1]); err != nil {
+				logger.Warn("cannot undo delete", "error", err)
+				return
+			}
+		}
+	})
+}
+
+// selectedText returns the text of one entry, or several entries merged
+// with the configured separator.
+func (f pickFlow) selectedText(entries []store.Entry, ids []int) (string, error) {
+	if len(ids) == 1 {
+		return entries[ids[0]-1].Text, nil
+	}
+	return mergeByIndex(entries, ids, f.cfg.Merge.Separator)
+}
+
+// chooseAction shows the secondary menu with every popup action and, for a
+// single entry, the quick actions for its kind.
+func (f pickFlow) chooseAction(memStore *store.Store, entries []store.Entry, sel popup.Selection) (bool, error) {
+	entry := entries[sel.ID-1]
+	pinLabel := i18n.T("menu.pin")
+	switch {
+	case len(sel.IDs) > 1:
+		pinLabel = i18n.T("menu.pin.toggle")
+	case entry.Pinned:
+		pinLabel = i18n.T("menu.unpin")
+	}
+	options := []popup.Option{
+		{Key: string(popup.ActionCopy), Label: i18n.T("menu.copy")},
+		{Key: string(popup.ActionCopyPlain), Label: i18n.T("menu.plain")},
+		{Key: string(popup.ActionEdit), Label: i18n.T("menu.edit")},
+	}
+	if len(sel.IDs) <= 1 {
+		for _, a := range actions.For(entry.Kind, f.cfg.Actions) {
+			options = append(options, popup.Option{Key: "action:" + a.Name, Label: a.Label})
+		}
+	}
+	options = append(options, popup.Option{Key: string(popup.ActionTransform), Label: i18n.T("menu.transform")})
+	if _, isRegister := f.register(sel.ID); !isRegister || len(sel.IDs) > 1 {
+		options = append(options, popup.Option{Key: string(popup.ActionPin), Label: pinLabel})
+	}
+	options = append(options, popup.Option{Key: string(popup.ActionDelete), Label: i18n.T("menu.delete")})
+
+	key, err := f.ui.Choose(i18n.T("menu.choose.action"), options)
+	if err != nil {
+		if errors.Is(err, popup.ErrCanceled) {
+			return true, nil
+		}
+		return false, err
+	}
+	if name, ok := strings.CutPrefix(key, "action:"); ok {
+		_ = f.ui.Close()
+		return false, runEntryAction(entry, sel.ID, name, f.cfg)
+	}
+	sel.Action = popup.Action(key)
+	return f.perform(memStore, entries, sel)
+}
+
+func (f pickFlow) chooseTransform(memStore *store.Store, text string) (bool, error) {
+	transforms := transform.List()
+	options := make([]popup.Option, 0, len(transforms))
+	for _, t := range transforms {
+		options = append(options, popup.Option{Key: t.Name, Label: transformLabel(t)})
+	}
+	name, err := f.ui.Choose(i18n.T("menu.choose.transform"), options)
+	if err != nil {
+		if errors.Is(err, popup.ErrCanceled) {
+			return true, nil
+		}
+		return false, err
+	}
+	out, err := transform.Apply(name, text)
+	if err != nil {
+		return false, fail("transform", err)
+	}
+	_ = f.ui.Close()
+	if f.cfg.Transform.Save {
+		if err := memStore.Reload(); err != nil {
+			return false, fail("store", err)
+		}
+		if err := memStore.Add(out); err != nil {
+			return false, fail("store", err)
+		}
+	}
+	return false, f.deliver(out, false)
+}
+
+// chooseSnippet lists the snippets, expands the chosen one, asking for its
+// prompt fields, and delivers the result. Canceling reopens the history.
+func (f pickFlow) chooseSnippet() (bool, error) {
+	snippets, err := snippet.Load(f.cfg.Snippets)
+	if err != nil {
+		return false, fail("snippet", err)
+	}
+	if len(snippets) == 0 {
+		return false, failf("snippet", "err.snippet.none", snippet.DefaultDir())
+	}
+	options := make([]popup.Option, 0, len(snippets))
+	for _, s := range snippets {
+		options = append(options, popup.Option{Key: s.Name, Label: s.Name + "  " + popup.Summary(s.Text, 60)})
+	}
+	name, err := f.ui.Choose(i18n.T("menu.choose.snippet"), options)
+	if err != nil {
+		if errors.Is(err, popup.ErrCanceled) {
+			return true, nil
+		}
+		return false, err
+	}
+	s, _ := snippet.Lookup(snippets, name)
+	text, err := expandSnippet(s, f.ui.Ask)
+	if err != nil {
+		if errors.Is(err, popup.ErrCanceled) {
+			return true, nil
+		}
+		return false, err
+	}
+	_ = f.ui.Close()
+	return false, f.deliver(text, true)
+}
+
+// copyText writes text to the clipboard. Unless capture is set, the daemon
+// is told to skip it.
+func copyText(text string, capture bool) error {
+	if !capture {
+		return writePickText(text)
+	}
+	clipboardProvider, err := clipboard.NewProvider()
+	if err != nil {
+		return fail("pick", err)
+	}
+	if err := clipboardProvider.Write(text); err != nil {
+		return fail("pick", err)
+	}
+	notifyPicked(text)
+	return nil
+}
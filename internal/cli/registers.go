@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"stashclip/internal/clipboard"
+	"stashclip/internal/store"
+)
+
+// runSet stores text, the clipboard (the default) or a history entry in a
+// named register.
+func runSet(args []string) error {
+	if len(args) == 0 {
+		return failf("set", "err.register.name")
+	}
+	name, rest := args[0], args[1:]
+	var text string
+	switch {
+	case len(rest) == 0 || (len(rest) == 1 && rest[0] == "--from-clipboard"):
+		clipboardProvider, err := clipboard.NewProvider()
+		if err != nil {
+			return fail("set", err)
+		}
+		text, err = clipboardProvider.Read()
+		if err != nil {
+			return fail("set", err)
+		}
+	case rest[0] == "--id" || strings.HasPrefix(rest[0], "--id="):
+		value, hasValue := strings.CutPrefix(rest[0], "--id=")
+		if !hasValue {
+			if len(rest) != 2 {
+				return failf("set", "err.value", "--id")
+			}
+			value = rest[1]
+		} else if len(rest) != 1 {
+			return failf("set", "err.args")
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return failf("set", "err.index", value)
+		}
+		memStore, err := newStore()
+		if err != nil {
+			return err
+		}
+		entries := memStore.List()
+		if n < 1 || n > len(entries) {
+			return failf("set", "err.range", n)
+		}
+		text = entries[n-1].Text
+	default:
+		text = strings.Join(rest, " ")
+	}
+	if text == "" {
+		return failf("set", "err.set.empty")
+	}
+	regs, err := newRegisters()
+	if err != nil {
+		return err
+	}
+	if err := regs.Set(name, text); err != nil {
+		return fail("set", err)
+	}
+	return nil
+}
+
+// runGet copies a register to the clipboard, or prints it with --print.
+func runGet(args []string) error {
+	printOnly := false
+	var names []string
+	for _, arg := range args {
+		if arg == "--print" {
+			printOnly = true
+			continue
+		}
+		names = append(names, arg)
+	}
+	if len(names) != 1 {
+		return failf("get", "err.register.one")
+	}
+	regs, err := newRegisters()
+	if err != nil {
+		return err
+	}
+	reg, ok := regs.Get(names[0])
+	if !ok {
+		return failf("get", "err.register.unknown", names[0])
+	}
+	if printOnly {
+		fmt.Print(reg.Text)
+		return nil
+	}
+	return writePickText(reg.Text)
+}
+
+func runRegisters(args []string) error {
+	if len(args) > 0 {
+		return failf("registers", "err.args")
+	}
+	regs, err := newRegisters()
+	if err != nil {
+		return err
+	}
+	for _, reg := range regs.List() {
+		text := strings.ReplaceAll(reg.Text, "\n", "\\n")
+		text = strings.ReplaceAll(text, "\t", "\\t")
+		fmt.Printf("%s\t%s\t%s\n", reg.Name, reg.UpdatedAt.Format(time.RFC3339), text)
+	}
+	return nil
+}
+
+func newRegisters() (*store.Registers, error) {
+	regs, err := store.NewRegisters()
+	if err != nil {
+		return nil, fail("store", err)
+	}
+	regs.SetLogger(logger.With("component", "store"))
+	return regs, nil
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"stashclip/internal/clipboard"
+	"stashclip/internal/popup"
+	"stashclip/internal/snippet"
+)
+
+// runSnippet lists the snippets, or expands one and copies it (or prints
+// it with --print). Prompt fields are asked through the popup backend.
+func runSnippet(args []string) error {
+	printOnly := false
+	var names []string
+	for _, arg := range args {
+		if arg == "--print" {
+			printOnly = true
+			continue
+		}
+		names = append(names, arg)
+	}
+	if len(names) > 1 {
+		return failf("snippet", "err.args")
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	snippets, err := snippet.Load(cfg.Snippets)
+	if err != nil {
+		return fail("snippet", err)
+	}
+	if len(names) == 0 {
+		for _, s := range snippets {
+			fmt.Printf("%s\t%s\n", s.Name, popup.Summary(s.Text, 80))
+		}
+		return nil
+	}
+	s, ok := snippet.Lookup(snippets, names[0])
+	if !ok {
+		return failf("snippet", "err.snippet.unknown", names[0])
+	}
+	if err := configurePopup(cfg); err != nil {
+		return err
+	}
+	text, err := expandSnippet(s, popup.Ask)
+	if err != nil {
+		if errors.Is(err, popup.ErrCanceled) {
+			return nil
+		}
+		return err
+	}
+	if printOnly {
+		fmt.Print(text)
+		return nil
+	}
+	return copyText(text, true)
+}
+
+// expandSnippet fills the placeholders of s, asking prompt fields with ask.
+func expandSnippet(s snippet.Snippet, ask func(prompt string) (string, error)) (string, error) {
+	e := snippet.Expander{
+		Clipboard: func() (string, error) {
+			clipboardProvider, err := clipboard.NewProvider()
+			if err != nil {
+				return "", err
+			}
+			return clipboardProvider.Read()
+		},
+		Ask: ask,
+	}
+	text, err := e.Expand(s.Text)
+	if err != nil {
+		if errors.Is(err, popup.ErrCanceled) {
+			return "", err
+		}
+		return "", fail("snippet", err)
+	}
+	return text, nil
+}
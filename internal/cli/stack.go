@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"strconv"
+
+	"stashclip/internal/clipboard"
+	"stashclip/internal/dbusapi"
+	"stashclip/internal/store"
+)
+
+// runPush stashes the current clipboard on the stack.
+func runPush(args []string) error {
+	if len(args) > 0 {
+		return failf("push", "err.args")
+	}
+	clipboardProvider, err := clipboard.NewProvider()
+	if err != nil {
+		return fail("push", err)
+	}
+	text, err := clipboardProvider.Read()
+	if err != nil {
+		return fail("push", err)
+	}
+	if text == "" {
+		return failf("push", "err.clipboard.empty")
+	}
+	stack, err := newStack()
+	if err != nil {
+		return err
+	}
+	if err := stack.Push(text); err != nil {
+		return fail("store", err)
+	}
+	return nil
+}
+
+// runPop restores the top of the stack to the clipboard and removes it.
+func runPop(args []string) error {
+	if len(args) > 0 {
+		return failf("pop", "err.args")
+	}
+	stack, err := newStack()
+	if err != nil {
+		return err
+	}
+	// The text leaves the stack only once it is on the clipboard.
+	text, ok := stack.Peek()
+	if !ok {
+		return failf("pop", "err.stack.empty")
+	}
+	if err := writePickText(text); err != nil {
+		return err
+	}
+	if _, _, err := stack.Pop(); err != nil {
+		return fail("store", err)
+	}
+	return nil
+}
+
+// runQueue asks the daemon to serve entries one paste at a time.
+func runQueue(args []string) error {
+	if len(args) == 1 && args[0] == "--stop" {
+		if err := dbusapi.Call("StopQueue"); err != nil {
+			return fail("queue", err)
+		}
+		return nil
+	}
+	if len(args) == 0 {
+		return failf("queue", "err.ids")
+	}
+	ids := make([]uint32, 0, len(args))
+	for _, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return failf("queue", "err.index", arg)
+		}
+		ids = append(ids, uint32(n))
+	}
+	if err := dbusapi.Call("Queue", ids); err != nil {
+		return fail("queue", err)
+	}
+	return nil
+}
+
+func newStack() (*store.Stack, error) {
+	stack, err := store.NewStack()
+	if err != nil {
+		return nil, fail("store", err)
+	}
+	stack.SetLogger(logger.With("component", "store"))
+	return stack, nil
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"stashclip/internal/i18n"
+	"stashclip/internal/store"
+)
+
+// runStoreCommand checks, repairs or restores the history file. It works
+// on the file directly, since loading it would already repair it.
+func runStoreCommand(args []string) error {
+	if len(args) == 0 {
+		return failf("store", "err.store.action")
+	}
+	path := store.DefaultPath()
+	if path == "" {
+		return failf("store", "err.store.path")
+	}
+	action, rest := args[0], args[1:]
+	if action != "restore" && len(rest) > 0 {
+		return failf("store", "err.args")
+	}
+	switch action {
+	case "check":
+		return runStoreCheck(path)
+	case "repair":
+		result, err := store.Repair(path)
+		if err != nil {
+			return fail("store", err)
+		}
+		if result.Quarantine == "" {
+			fmt.Println(i18n.T("cli.store.healthy", result.Entries))
+			return nil
+		}
+		fmt.Println(i18n.T("cli.store.repaired", result.Entries, result.Dropped, result.Quarantine))
+		return nil
+	case "restore":
+		if len(rest) == 0 {
+			printBackups(store.Backups(path))
+			return nil
+		}
+		if len(rest) > 1 {
+			return failf("store", "err.args")
+		}
+		replaced, err := store.Restore(path, rest[0])
+		if err != nil {
+			return fail("store", err)
+		}
+		fmt.Println(i18n.T("cli.store.restored", rest[0]))
+		if replaced != "" {
+			fmt.Println(i18n.T("cli.store.replaced", replaced))
+		}
+		return nil
+	default:
+		return failf("store", "err.action", action)
+	}
+}
+
+// runStoreCheck prints a report and fails when the file needs a repair.
+func runStoreCheck(path string) error {
+	report, err := store.Check(path)
+	if err != nil {
+		return fail("store", err)
+	}
+	if report.Damage != nil {
+		fmt.Println(i18n.T("cli.store.damaged", report.Path, report.Damage))
+		fmt.Println(i18n.T("cli.store.salvage", report.Salvageable))
+	} else {
+		fmt.Println(i18n.T("cli.store.ok", report.Path, report.Version, report.Entries))
+		if report.Invalid > 0 {
+			fmt.Println(i18n.T("cli.store.invalid", report.Invalid))
+		}
+	}
+	printBackups(report.Backups)
+	if report.Damage != nil || report.Invalid > 0 {
+		return failf("store", "err.store.check")
+	}
+	return nil
+}
+
+func printBackups(backups []string) {
+	if len(backups) == 0 {
+		fmt.Println(i18n.T("cli.store.nobackups"))
+		return
+	}
+	fmt.Println(i18n.T("cli.store.backups"))
+	for _, b := range backups {
+		fmt.Println("  " + filepath.Base(b))
+	}
+}
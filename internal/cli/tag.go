@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"stashclip/internal/tags"
+)
+
+// runTag shows the tags of an entry, or changes them from a comma-separated
+// list where tags prefixed with "-" are removed.
+func runTag(args []string) error {
+	if len(args) == 0 {
+		return failf("tag", "err.id")
+	}
+	if len(args) > 2 {
+		return failf("tag", "err.args")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return failf("tag", "err.index", args[0])
+	}
+	memStore, err := newStore()
+	if err != nil {
+		return err
+	}
+	entries := memStore.List()
+	if n < 1 || n > len(entries) {
+		return failf("tag", "err.range", n)
+	}
+	if len(args) == 1 {
+		fmt.Println(strings.Join(entries[n-1].Tags, ","))
+		return nil
+	}
+	var add, remove []string
+	for _, t := range strings.Split(args[1], ",") {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(t), "-"); ok {
+			remove = append(remove, name)
+		} else {
+			add = append(add, t)
+		}
+	}
+	updated, err := memStore.Tag(n-1, tags.Normalize(add), tags.Normalize(remove))
+	if err != nil {
+		return fail("store", err)
+	}
+	fmt.Println(strings.Join(updated, ","))
+	return nil
+}
+
+// runTags lists every tag in use with its number of entries.
+func runTags(args []string) error {
+	if len(args) > 0 {
+		return failf("tags", "err.args")
+	}
+	memStore, err := newStore()
+	if err != nil {
+		return err
+	}
+	counts := map[string]int{}
+	for _, entry := range memStore.List() {
+		for _, t := range entry.Tags {
+			counts[t]++
+		}
+	}
+	names := make([]string, 0, len(counts))
+	for t := range counts {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	for _, t := range names {
+		fmt.Printf("%s\t%d\n", t, counts[t])
+	}
+	return nil
+}
@@ -41,24 +41,36 @@ func ShouldIgnore(text string) bool {
 	path := ignoredPath()
 	data, err := os.ReadFile(path)
 	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Warn("cannot read ignore marker", "path", path, "error", err)
+		}
 		return false
 	}
 	var entry ignoredEntry
 	if err := json.Unmarshal(data, &entry); err != nil {
-		_ = os.Remove(path)
+		logger.Warn("discarding malformed ignore marker", "path", path, "error", err)
+		removeIgnored(path)
 		return false
 	}
 	if time.Now().After(entry.ExpiresAt) {
-		_ = os.Remove(path)
+		logger.Debug("discarding expired ignore marker", "path", path)
+		removeIgnored(path)
 		return false
 	}
 	if entry.Hash != hashText(text) {
 		return false
 	}
-	_ = os.Remove(path)
+	logger.Debug("ignoring app-originated clipboard change")
+	removeIgnored(path)
 	return true
 }
 
+func removeIgnored(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Warn("cannot remove ignore marker", "path", path, "error", err)
+	}
+}
+
 func ignoredPath() string {
 	base := store.DefaultPath()
 	if base == "" {
@@ -0,0 +1,17 @@
+package clipboard
+
+import (
+	"log/slog"
+
+	"stashclip/internal/logging"
+)
+
+var logger = logging.Discard()
+
+// SetLogger sets the logger used by clipboard providers and watchers.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = logging.Discard()
+	}
+	logger = l
+}
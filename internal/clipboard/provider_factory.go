@@ -4,7 +4,7 @@ import "fmt"
 
 // NewProvider returns a clipboard provider for the current desktop session.
 func NewProvider() (ClipboardProvider, error) {
-	switch sessionType() {
+	switch SessionType() {
 	case "wayland":
 		if hasCommand("wl-copy") && hasCommand("wl-paste") {
 			return NewWayland(), nil
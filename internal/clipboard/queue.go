@@ -0,0 +1,22 @@
+package clipboard
+
+import "time"
+
+// PasteQueue is implemented by watchers that can serve queued texts one
+// paste at a time, such as to fill a form field by field.
+type PasteQueue interface {
+	// StartQueue puts texts on the clipboard in order, moving to the next
+	// one after each paste. progress is called with the number of texts
+	// left after every paste, and with 0 once the queue ends or another
+	// application takes the clipboard.
+	StartQueue(texts []string, progress func(left int)) error
+	// StopQueue ends the queue.
+	StopQueue()
+	// Serving reports whether a queue is being served; the clipboard must
+	// not be read meanwhile, since a read can count as a paste.
+	Serving() bool
+}
+
+// queueSettle is how long requests after a paste still get the same text;
+// applications often ask for several targets per paste.
+const queueSettle = 300 * time.Millisecond
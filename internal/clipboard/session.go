@@ -6,7 +6,8 @@ import (
 	"strings"
 )
 
-func sessionType() string {
+// SessionType returns "wayland", "x11" or "" when no display is available.
+func SessionType() string {
 	t := strings.ToLower(strings.TrimSpace(os.Getenv("XDG_SESSION_TYPE")))
 	if t == "wayland" || t == "x11" {
 		return t
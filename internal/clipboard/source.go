@@ -0,0 +1,55 @@
+package clipboard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// SourceReporter is implemented by watchers that know which application
+// made the latest change. The Wayland watcher does not: wl-paste has no
+// way to tell which client set the selection.
+type SourceReporter interface {
+	// Source returns the application that owns the clipboard after the
+	// latest change, or "" when it is unknown.
+	Source() string
+}
+
+// WindowApp names the application of an X11 window: the class of its
+// WM_CLASS property, or the command of its _NET_WM_PID. The paste step
+// uses it for the focused window too.
+func WindowApp(conn *xgb.Conn, window xproto.Window) string {
+	if class := windowProperty(conn, window, "WM_CLASS"); class != nil {
+		// WM_CLASS holds the instance and the class, each NUL-terminated.
+		parts := bytes.Split(bytes.TrimRight(class, "\x00"), []byte{0})
+		if name := string(parts[len(parts)-1]); name != "" {
+			return name
+		}
+	}
+	pid := windowProperty(conn, window, "_NET_WM_PID")
+	if len(pid) < 4 {
+		return ""
+	}
+	comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", xgb.Get32(pid)))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// windowProperty returns the value of a window property, or nil.
+func windowProperty(conn *xgb.Conn, window xproto.Window, name string) []byte {
+	atom, err := xproto.InternAtom(conn, true, uint16(len(name)), name).Reply()
+	if err != nil || atom.Atom == xproto.AtomNone {
+		return nil
+	}
+	reply, err := xproto.GetProperty(conn, false, window, atom.Atom, xproto.GetPropertyTypeAny, 0, 1024).Reply()
+	if err != nil || reply.ValueLen == 0 {
+		return nil
+	}
+	return reply.Value
+}
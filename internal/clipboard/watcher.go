@@ -11,7 +11,7 @@ type EventWatcher interface {
 
 // NewEventWatcher returns an event watcher for the current desktop session.
 func NewEventWatcher() (EventWatcher, error) {
-	switch sessionType() {
+	switch SessionType() {
 	case "wayland":
 		if !hasCommand("wl-paste") {
 			return nil, fmt.Errorf("wayland detected, but wl-paste not found")
@@ -4,36 +4,38 @@ import (
 	"bufio"
 	"io"
 	"os/exec"
+	"sync"
 )
 
+// watchCommand runs for every clipboard change. wl-paste pipes the
+// clipboard to it, so each run reads the clipboard like a paste would.
+var watchCommand = []string{"wl-paste", "--watch", "sh", "-c", "printf '\\n'"}
+
 // WaylandEventWatcher notifies when the Wayland clipboard changes.
 type WaylandEventWatcher struct {
-	cmd    *exec.Cmd
 	events chan struct{}
 	errs   chan error
+	queue  waylandQueue
+
+	mu sync.Mutex
+	// cmd is the running wl-paste watcher, nil while paused; done is
+	// closed once its output is read to the end.
+	cmd    *exec.Cmd
 	done   chan struct{}
+	closed bool
 }
 
 // NewWaylandEventWatcher subscribes to Wayland clipboard change events.
 func NewWaylandEventWatcher() (*WaylandEventWatcher, error) {
-	cmd := exec.Command("wl-paste", "--watch", "sh", "-c", "printf '\\n'")
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	cmd.Stderr = io.Discard
-
-	if err := cmd.Start(); err != nil {
-		return nil, err
-	}
-
 	w := &WaylandEventWatcher{
-		cmd:    cmd,
 		events: make(chan struct{}, 1),
 		errs:   make(chan error, 1),
-		done:   make(chan struct{}),
 	}
-	go w.loop(stdout)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.watch(); err != nil {
+		return nil, err
+	}
 	return w, nil
 }
 
@@ -49,19 +51,67 @@ func (w *WaylandEventWatcher) Errors() <-chan error {
 
 // Close stops the watcher process.
 func (w *WaylandEventWatcher) Close() error {
-	if w.cmd == nil || w.cmd.Process == nil {
+	w.StopQueue()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
 		return nil
 	}
+	w.closed = true
+	w.pause()
+	close(w.events)
+	return nil
+}
+
+// watch starts the wl-paste watcher. It must be called with mu held.
+func (w *WaylandEventWatcher) watch() error {
+	cmd := exec.Command(watchCommand[0], watchCommand[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stderr = io.Discard
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	w.cmd, w.done = cmd, make(chan struct{})
+	go w.loop(cmd, stdout, w.done)
+	return nil
+}
+
+// pause stops the wl-paste watcher, such as while a paste queue is served,
+// and drops a change it reported but the daemon did not take yet. It must
+// be called with mu held.
+func (w *WaylandEventWatcher) pause() {
+	if w.cmd == nil {
+		return
+	}
 	_ = w.cmd.Process.Kill()
 	<-w.done
-	w.cmd = nil
-	return nil
+	w.cmd, w.done = nil, nil
+	select {
+	case <-w.events:
+	default:
+	}
 }
 
-func (w *WaylandEventWatcher) loop(r io.Reader) {
-	defer close(w.done)
-	defer close(w.events)
+// resume restarts the watcher after pause.
+func (w *WaylandEventWatcher) resume() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed || w.cmd != nil {
+		return
+	}
+	if err := w.watch(); err != nil {
+		logger.Error("cannot restart wayland watcher", "error", err)
+		select {
+		case w.errs <- err:
+		default:
+		}
+	}
+}
 
+func (w *WaylandEventWatcher) loop(cmd *exec.Cmd, r io.Reader, done chan struct{}) {
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		select {
@@ -70,17 +120,34 @@ func (w *WaylandEventWatcher) loop(r io.Reader) {
 		}
 	}
 	if err := scanner.Err(); err != nil {
+		logger.Error("wayland watcher read failed", "error", err)
 		select {
 		case w.errs <- err:
 		default:
 		}
 	}
-	if w.cmd != nil {
-		if err := w.cmd.Wait(); err != nil {
-			select {
-			case w.errs <- err:
-			default:
-			}
+	err := cmd.Wait()
+	close(done)
+
+	w.mu.Lock()
+	paused := w.cmd != cmd
+	w.mu.Unlock()
+	if paused {
+		return
+	}
+	// wl-paste exited on its own.
+	if err != nil {
+		logger.Debug("wl-paste watcher exited", "error", err)
+		select {
+		case w.errs <- err:
+		default:
 		}
 	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.closed = true
+		w.cmd = nil
+		close(w.events)
+	}
 }
@@ -0,0 +1,120 @@
+package clipboard
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// waylandQueue serves queued texts with "wl-copy --paste-once", which
+// exits after the first paste. The watcher is paused meanwhile, since
+// wl-paste reading each change would count as a paste.
+type waylandQueue struct {
+	mu   sync.Mutex
+	cmd  *exec.Cmd
+	stop chan struct{}
+}
+
+// StartQueue serves texts one paste at a time; see PasteQueue.
+func (w *WaylandEventWatcher) StartQueue(texts []string, progress func(left int)) error {
+	if len(texts) == 0 {
+		return errors.New("paste queue is empty")
+	}
+	w.StopQueue()
+	stop := make(chan struct{})
+	w.queue.mu.Lock()
+	w.queue.stop = stop
+	w.mu.Lock()
+	w.pause()
+	w.mu.Unlock()
+	w.queue.mu.Unlock()
+	go w.serveQueue(texts, progress, stop)
+	return nil
+}
+
+// Serving reports whether a paste queue is being served.
+func (w *WaylandEventWatcher) Serving() bool {
+	w.queue.mu.Lock()
+	defer w.queue.mu.Unlock()
+	return w.queue.stop != nil
+}
+
+// StopQueue ends the paste queue, if one runs, and clears the clipboard.
+func (w *WaylandEventWatcher) StopQueue() {
+	w.queue.mu.Lock()
+	defer w.queue.mu.Unlock()
+	if w.queue.stop == nil {
+		return
+	}
+	close(w.queue.stop)
+	w.queue.stop = nil
+	if w.queue.cmd != nil && w.queue.cmd.Process != nil {
+		_ = w.queue.cmd.Process.Kill()
+	}
+}
+
+func (w *WaylandEventWatcher) serveQueue(texts []string, progress func(left int), stop chan struct{}) {
+	defer progress(0)
+	defer w.endQueue(stop)
+	for i, text := range texts {
+		if err := MarkIgnored(text); err != nil {
+			logger.Warn("cannot mark queued text", "error", err)
+		}
+		cmd := exec.Command("wl-copy", "--foreground", "--paste-once")
+		cmd.Stdin = strings.NewReader(text)
+		w.queue.mu.Lock()
+		select {
+		case <-stop:
+			w.queue.mu.Unlock()
+			return
+		default:
+		}
+		if err := cmd.Start(); err != nil {
+			w.queue.mu.Unlock()
+			logger.Warn("cannot serve paste queue", "error", err)
+			return
+		}
+		w.queue.cmd = cmd
+		w.queue.mu.Unlock()
+		_ = cmd.Wait()
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		// wl-copy also exits when another application copies; then the
+		// clipboard holds that text instead of being empty.
+		if current, err := NewWayland().Read(); err == nil && current != "" && current != text {
+			logger.Debug("wayland paste queue replaced by a new copy")
+			return
+		}
+		left := len(texts) - i - 1
+		logger.Debug("wayland paste queue advanced", "left", left)
+		if left > 0 {
+			progress(left)
+		}
+	}
+	// The last text stays on the clipboard, as on X11.
+	last := texts[len(texts)-1]
+	if err := MarkIgnored(last); err != nil {
+		logger.Warn("cannot mark queued text", "error", err)
+	}
+	if err := NewWayland().Write(last); err != nil {
+		logger.Warn("cannot restore last queued text", "error", err)
+	}
+}
+
+// endQueue resumes the watcher once the queue served with stop ends,
+// unless a new queue replaced it.
+func (w *WaylandEventWatcher) endQueue(stop chan struct{}) {
+	w.queue.mu.Lock()
+	defer w.queue.mu.Unlock()
+	if w.queue.stop == stop {
+		w.queue.stop = nil
+		w.queue.cmd = nil
+	}
+	if w.queue.stop == nil {
+		w.resume()
+	}
+}
@@ -0,0 +1,140 @@
+package clipboard
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeWlCopy keeps the clipboard in $FAKE_CLIP/clip and bumps
+// $FAKE_CLIP/seq on every copy. With --paste-once it waits until a reader
+// removes its $FAKE_CLIP/once marker.
+const fakeWlCopy = `#!/bin/sh
+d=$FAKE_CLIP
+once=
+for a; do [ "$a" = --paste-once ] && once=1; done
+cat > "$d/clip.tmp" && mv "$d/clip.tmp" "$d/clip"
+if [ -n "$once" ]; then echo $$ > "$d/once"; else rm -f "$d/once"; fi
+echo $$ >> "$d/seq"
+while [ -n "$once" ] && [ "$(cat "$d/once" 2>/dev/null)" = "$$" ]; do sleep 0.01; done
+`
+
+// fakeWlPaste prints the clipboard, which uses up a paste-once offer.
+// With --watch it pipes the clipboard to its command on every copy.
+const fakeWlPaste = `#!/bin/sh
+d=$FAKE_CLIP
+paste() { cat "$d/clip" 2>/dev/null; rm -f "$d/once"; }
+if [ "$1" = --watch ]; then
+	shift
+	last=x
+	while :; do
+		s=$(cat "$d/seq" 2>/dev/null)
+		if [ "$s" != "$last" ]; then last=$s; paste | "$@"; fi
+		sleep 0.01
+	done
+fi
+paste
+`
+
+func fakeWayland(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	bin, clip := t.TempDir(), t.TempDir()
+	for name, script := range map[string]string{"wl-copy": fakeWlCopy, "wl-paste": fakeWlPaste} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_CLIP", clip)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	return clip
+}
+
+func TestWaylandQueueWithWatcher(t *testing.T) {
+	clip := fakeWayland(t)
+	w, err := NewWaylandEventWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	progress := make(chan int, 8)
+	if err := w.StartQueue([]string{"a", "b", "c"}, func(left int) { progress <- left }); err != nil {
+		t.Fatal(err)
+	}
+	if !w.Serving() {
+		t.Fatal("Serving() = false after StartQueue")
+	}
+	// Nothing was pasted: the watcher must not have used up the offer.
+	select {
+	case left := <-progress:
+		t.Fatalf("queue advanced to %d without a paste", left)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	for _, want := range []struct {
+		text string
+		left int
+	}{{"a", 2}, {"b", 1}, {"c", 0}} {
+		waitOffer(t, clip)
+		got, err := NewWayland().Read()
+		if err != nil || got != want.text {
+			t.Fatalf("paste = %q, %v; want %q", got, err, want.text)
+		}
+		select {
+		case left := <-progress:
+			if left != want.left {
+				t.Errorf("after pasting %q: %d left, want %d", got, left, want.left)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no progress after pasting %q", got)
+		}
+	}
+
+	// The watcher runs again once the queue ends.
+	deadline := time.Now().Add(5 * time.Second)
+	for w.Serving() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if w.Serving() {
+		t.Fatal("Serving() = true after the queue ended")
+	}
+	drain(w.Events())
+	if err := NewWayland().Write("d"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.Events():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event for a copy after the queue")
+	}
+}
+
+// waitOffer waits until wl-copy offers a paste-once text.
+func waitOffer(t *testing.T, clip string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(filepath.Join(clip, "once")); err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no paste-once offer")
+}
+
+func drain(events <-chan struct{}) {
+	deadline := time.After(200 * time.Millisecond)
+	for {
+		select {
+		case <-events:
+		case <-deadline:
+			return
+		}
+	}
+}
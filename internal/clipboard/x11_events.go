@@ -1,7 +1,9 @@
 package clipboard
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/BurntSushi/xgb"
 	"github.com/BurntSushi/xgb/xfixes"
@@ -10,12 +12,18 @@ import (
 
 // X11EventWatcher notifies when the X11 clipboard changes.
 type X11EventWatcher struct {
-	conn   *xgb.Conn
-	events chan struct{}
-	errs   chan error
+	conn    *xgb.Conn
+	manager *x11Manager
+	events  chan struct{}
+	errs    chan error
+
+	mu     sync.Mutex
+	source string
 }
 
-// NewX11EventWatcher subscribes to X11 clipboard change events.
+// NewX11EventWatcher subscribes to X11 clipboard change events. Unless
+// another clipboard manager runs, it also keeps the clipboard alive after
+// its owner exits; see Keep.
 func NewX11EventWatcher() (*X11EventWatcher, error) {
 	conn, err := xgb.NewConn()
 	if err != nil {
@@ -48,10 +56,16 @@ func NewX11EventWatcher() (*X11EventWatcher, error) {
 		return nil, err
 	}
 
+	manager, err := newX11Manager(conn)
+	if err != nil {
+		logger.Warn("cannot become x11 clipboard manager", "error", err)
+	}
+
 	w := &X11EventWatcher{
-		conn:   conn,
-		events: make(chan struct{}, 1),
-		errs:   make(chan error, 1),
+		conn:    conn,
+		manager: manager,
+		events:  make(chan struct{}, 1),
+		errs:    make(chan error, 1),
 	}
 
 	go w.loop()
@@ -68,6 +82,42 @@ func (w *X11EventWatcher) Errors() <-chan error {
 	return w.errs
 }
 
+// Keep records the text read after the latest change, served again when
+// the application that copied it exits.
+func (w *X11EventWatcher) Keep(text string) {
+	if w.manager != nil {
+		w.manager.Keep(text)
+	}
+}
+
+// Source returns the application of the window that owned the clipboard
+// at the latest change; see SourceReporter.
+func (w *X11EventWatcher) Source() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.source
+}
+
+// StartQueue serves texts one paste at a time; see PasteQueue.
+func (w *X11EventWatcher) StartQueue(texts []string, progress func(left int)) error {
+	if w.manager == nil {
+		return errors.New("paste queue needs stashclip to be the clipboard manager")
+	}
+	return w.manager.StartQueue(texts, progress)
+}
+
+// Serving reports whether a paste queue is being served.
+func (w *X11EventWatcher) Serving() bool {
+	return w.manager != nil && w.manager.Serving()
+}
+
+// StopQueue ends the paste queue, if one runs.
+func (w *X11EventWatcher) StopQueue() {
+	if w.manager != nil {
+		w.manager.StopQueue()
+	}
+}
+
 // Close releases the X11 connection.
 func (w *X11EventWatcher) Close() error {
 	if w.conn == nil {
@@ -81,7 +131,14 @@ func (w *X11EventWatcher) Close() error {
 func (w *X11EventWatcher) loop() {
 	for {
 		event, err := w.conn.WaitForEvent()
+		if xerr, ok := err.(xgb.Error); ok {
+			// Protocol errors, such as a requestor window that vanished
+			// mid-transfer, do not end the watcher.
+			logger.Debug("x11 request failed", "error", xerr)
+			continue
+		}
 		if err != nil {
+			logger.Error("x11 watcher failed", "error", err)
 			select {
 			case w.errs <- err:
 			default:
@@ -94,8 +151,22 @@ func (w *X11EventWatcher) loop() {
 			return
 		}
 
-		switch event.(type) {
+		if w.manager != nil && w.manager.handle(event) {
+			continue
+		}
+
+		switch ev := event.(type) {
 		case xfixes.SelectionNotifyEvent:
+			logger.Debug("x11 selection event", "subtype", ev.Subtype, "owner", ev.Owner)
+			// The owner is looked up now, since it may be gone by the time
+			// the daemon reads the clipboard.
+			source := ""
+			if ev.Owner != xproto.WindowNone {
+				source = WindowApp(w.conn, ev.Owner)
+			}
+			w.mu.Lock()
+			w.source = source
+			w.mu.Unlock()
 			select {
 			case w.events <- struct{}{}:
 			default:
@@ -0,0 +1,502 @@
+package clipboard
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xfixes"
+	"github.com/BurntSushi/xgb/xproto"
+)
+
+// Keeper is implemented by watchers that keep the clipboard alive after
+// the application that copied it exits.
+type Keeper interface {
+	// Keep records the text the daemon read after the latest change. It
+	// is served again when its owner goes away; texts never kept, such as
+	// while capture is paused, are not.
+	Keep(text string)
+}
+
+// maxChunk caps property writes; larger texts use the INCR protocol.
+const maxChunk = 256 * 1024
+
+// maxProperty is the longest property read, in 32-bit units.
+const maxProperty = 1 << 26
+
+// saveTimeout is how long the owner has to hand over its clipboard when it
+// asks the manager to save it.
+const saveTimeout = 2 * time.Second
+
+// x11Manager acts as the clipboard manager described by the ICCCM and
+// freedesktop CLIPBOARD_MANAGER convention: it takes the CLIPBOARD
+// selection over when its owner window is destroyed or asks to save its
+// targets, and serves the last kept text from a hidden window.
+type x11Manager struct {
+	conn   *xgb.Conn
+	window xproto.Window
+	chunk  int
+	atoms  map[string]xproto.Atom
+
+	mu      sync.Mutex
+	kept    string
+	serving string
+	owned   bool
+
+	// queue holds the texts left in paste queue mode, the served one first.
+	queue    []string
+	progress func(left int)
+	pasted   *time.Timer
+
+	// transfers are INCR transfers in progress, by requestor and property.
+	transfers map[incrKey]*transfer
+
+	// saving is the SAVE_TARGETS request being served, if any.
+	saving *saveRequest
+}
+
+// saveRequest is a SAVE_TARGETS request waiting for the owner's text.
+type saveRequest struct {
+	ev       xproto.SelectionRequestEvent
+	property xproto.Atom
+	incr     bool
+	data     []byte
+	timer    *time.Timer
+}
+
+type transfer struct {
+	target xproto.Atom
+	data   []byte
+}
+
+type incrKey struct {
+	window   xproto.Window
+	property xproto.Atom
+}
+
+var managerAtoms = []string{
+	"CLIPBOARD", "CLIPBOARD_MANAGER", "SAVE_TARGETS", "TARGETS", "INCR", "NULL",
+	"UTF8_STRING", "TEXT", "text/plain", "text/plain;charset=utf-8", "STASHCLIP_SAVE",
+}
+
+// newX11Manager creates the hidden window and claims CLIPBOARD_MANAGER. It
+// returns nil when another manager already runs.
+func newX11Manager(conn *xgb.Conn) (*x11Manager, error) {
+	setup := xproto.Setup(conn)
+	screen := setup.DefaultScreen(conn)
+	m := &x11Manager{conn: conn, atoms: map[string]xproto.Atom{}, transfers: map[incrKey]*transfer{}}
+	for _, name := range managerAtoms {
+		reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+		if err != nil {
+			return nil, err
+		}
+		m.atoms[name] = reply.Atom
+	}
+	m.atoms["STRING"] = xproto.AtomString
+
+	m.chunk = int(setup.MaximumRequestLength)*4 - 64
+	if m.chunk <= 0 || m.chunk > maxChunk {
+		m.chunk = maxChunk
+	}
+
+	owner, err := xproto.GetSelectionOwner(conn, m.atoms["CLIPBOARD_MANAGER"]).Reply()
+	if err != nil {
+		return nil, err
+	}
+	if owner.Owner != xproto.WindowNone {
+		logger.Info("another clipboard manager is running, not keeping the clipboard", "owner", owner.Owner)
+		return nil, nil
+	}
+
+	window, err := xproto.NewWindowId(conn)
+	if err != nil {
+		return nil, err
+	}
+	// Property changes on the window bring the text of an owner that
+	// asks to save its clipboard.
+	err = xproto.CreateWindowChecked(conn, 0, window, screen.Root, 0, 0, 1, 1, 0,
+		xproto.WindowClassInputOnly, 0, xproto.CwEventMask, []uint32{xproto.EventMaskPropertyChange}).Check()
+	if err != nil {
+		return nil, err
+	}
+	m.window = window
+	xproto.SetSelectionOwner(conn, window, m.atoms["CLIPBOARD_MANAGER"], xproto.TimeCurrentTime)
+	return m, nil
+}
+
+// Keep records the text to serve when the clipboard owner goes away.
+func (m *x11Manager) Keep(text string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.kept = text
+}
+
+// handle processes an event and reports whether it was the manager's own
+// doing, which the watcher must not report as a clipboard change.
+func (m *x11Manager) handle(event xgb.Event) bool {
+	switch ev := event.(type) {
+	case xfixes.SelectionNotifyEvent:
+		if ev.Owner == m.window {
+			return true
+		}
+		switch ev.Subtype {
+		case xfixes.SelectionEventSetSelectionOwner:
+			// A new owner: forget the old text until the daemon keeps the
+			// new one.
+			m.mu.Lock()
+			m.kept, m.owned = "", false
+			m.mu.Unlock()
+			m.StopQueue()
+		case xfixes.SelectionEventSelectionWindowDestroy, xfixes.SelectionEventSelectionClientClose:
+			if m.own(ev.Timestamp) {
+				logger.Debug("x11 clipboard owner exited, keeping clipboard")
+				return true
+			}
+		}
+	case xproto.SelectionRequestEvent:
+		m.request(ev)
+		return true
+	case xproto.SelectionNotifyEvent:
+		if ev.Requestor == m.window {
+			m.converted(ev)
+		}
+		return true
+	case xproto.SelectionClearEvent:
+		if ev.Selection == m.atoms["CLIPBOARD"] {
+			m.mu.Lock()
+			m.owned = false
+			m.mu.Unlock()
+			m.StopQueue()
+		}
+		return true
+	case xproto.PropertyNotifyEvent:
+		switch {
+		case ev.State == xproto.PropertyDelete:
+			m.continueTransfer(incrKey{ev.Window, ev.Atom})
+		case ev.Window == m.window && ev.Atom == m.atoms["STASHCLIP_SAVE"]:
+			m.receiveChunk()
+		}
+		return true
+	}
+	return false
+}
+
+// StartQueue serves texts one paste at a time: a paste is a request for
+// the text, and the next text is served once it settles.
+func (m *x11Manager) StartQueue(texts []string, progress func(left int)) error {
+	if len(texts) == 0 {
+		return errors.New("paste queue is empty")
+	}
+	m.StopQueue()
+	m.mu.Lock()
+	m.queue, m.progress = append([]string(nil), texts...), progress
+	m.serving, m.owned = texts[0], true
+	xproto.SetSelectionOwner(m.conn, m.window, m.atoms["CLIPBOARD"], xproto.TimeCurrentTime)
+	m.mu.Unlock()
+	logger.Debug("x11 paste queue started", "texts", len(texts))
+	return nil
+}
+
+// StopQueue ends the paste queue, if one runs.
+func (m *x11Manager) StopQueue() {
+	m.mu.Lock()
+	progress := m.progress
+	active := m.queue != nil
+	m.queue, m.progress = nil, nil
+	if m.pasted != nil {
+		m.pasted.Stop()
+		m.pasted = nil
+	}
+	m.mu.Unlock()
+	if active && progress != nil {
+		progress(0)
+	}
+}
+
+// Serving reports whether a paste queue is being served.
+func (m *x11Manager) Serving() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.queue != nil
+}
+
+// pastedQueued schedules the next queued text after a paste.
+func (m *x11Manager) pastedQueued() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.queue == nil || m.pasted != nil {
+		return
+	}
+	m.pasted = time.AfterFunc(queueSettle, m.advanceQueue)
+}
+
+func (m *x11Manager) advanceQueue() {
+	m.mu.Lock()
+	m.pasted = nil
+	if m.queue == nil {
+		m.mu.Unlock()
+		return
+	}
+	m.queue = m.queue[1:]
+	left, progress := len(m.queue), m.progress
+	if left == 0 {
+		// The last text stays on the clipboard.
+		m.queue, m.progress = nil, nil
+	} else {
+		m.serving = m.queue[0]
+	}
+	m.mu.Unlock()
+	logger.Debug("x11 paste queue advanced", "left", left)
+	if progress != nil {
+		progress(left)
+	}
+}
+
+// own takes the CLIPBOARD selection to serve the kept text.
+func (m *x11Manager) own(t xproto.Timestamp) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.kept == "" {
+		return false
+	}
+	m.serving, m.owned = m.kept, true
+	xproto.SetSelectionOwner(m.conn, m.window, m.atoms["CLIPBOARD"], t)
+	return true
+}
+
+func (m *x11Manager) request(ev xproto.SelectionRequestEvent) {
+	property := ev.Property
+	if property == xproto.AtomNone {
+		// Obsolete clients ask for the target as the property.
+		property = ev.Target
+	}
+	var ok bool
+	switch ev.Selection {
+	case m.atoms["CLIPBOARD_MANAGER"]:
+		if ev.Target == m.atoms["SAVE_TARGETS"] {
+			// Answered once the owner handed its text over.
+			m.save(ev, property)
+			return
+		}
+		ok = m.replyManager(ev, property)
+	case m.atoms["CLIPBOARD"]:
+		ok = m.replyClipboard(ev, property)
+	}
+	m.notify(ev, property, ok)
+}
+
+// notify tells the requestor whether its request was served in property.
+func (m *x11Manager) notify(ev xproto.SelectionRequestEvent, property xproto.Atom, ok bool) {
+	if !ok {
+		property = xproto.AtomNone
+	}
+	notify := xproto.SelectionNotifyEvent{
+		Time:      ev.Time,
+		Requestor: ev.Requestor,
+		Selection: ev.Selection,
+		Target:    ev.Target,
+		Property:  property,
+	}
+	xproto.SendEvent(m.conn, false, ev.Requestor, xproto.EventMaskNoEvent, string(notify.Bytes()))
+}
+
+// replyManager answers the targets of CLIPBOARD_MANAGER.
+func (m *x11Manager) replyManager(ev xproto.SelectionRequestEvent, property xproto.Atom) bool {
+	if ev.Target != m.atoms["TARGETS"] {
+		return false
+	}
+	m.setAtoms(ev.Requestor, property, m.atoms["TARGETS"], m.atoms["SAVE_TARGETS"])
+	return true
+}
+
+// save starts serving an application that saves its clipboard before it
+// exits: the manager converts CLIPBOARD from it and answers once it has
+// the text. Texts the daemon did not keep, such as while capture is
+// paused, are not saved.
+func (m *x11Manager) save(ev xproto.SelectionRequestEvent, property xproto.Atom) {
+	m.mu.Lock()
+	busy, kept := m.saving != nil, m.kept != ""
+	if !busy && kept {
+		m.saving = &saveRequest{ev: ev, property: property}
+		m.saving.timer = time.AfterFunc(saveTimeout, func() { m.finishSave(false) })
+	}
+	m.mu.Unlock()
+	if busy || !kept {
+		m.notify(ev, property, false)
+		return
+	}
+	xproto.ConvertSelection(m.conn, m.window, m.atoms["CLIPBOARD"], m.atoms["UTF8_STRING"],
+		m.atoms["STASHCLIP_SAVE"], ev.Time)
+}
+
+// converted reads the owner's answer to the conversion started by save.
+func (m *x11Manager) converted(ev xproto.SelectionNotifyEvent) {
+	if ev.Property == xproto.AtomNone {
+		m.finishSave(false)
+		return
+	}
+	reply, err := xproto.GetProperty(m.conn, true, m.window, ev.Property, xproto.GetPropertyTypeAny, 0, maxProperty).Reply()
+	if err != nil {
+		m.finishSave(false)
+		return
+	}
+	if reply.Type == m.atoms["INCR"] {
+		// Deleting the property asked for the first chunk.
+		m.mu.Lock()
+		if m.saving != nil {
+			m.saving.incr = true
+		}
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Lock()
+	if m.saving != nil {
+		m.saving.data = reply.Value
+	}
+	m.mu.Unlock()
+	m.finishSave(true)
+}
+
+// receiveChunk reads the next chunk of an INCR conversion; the last one is
+// empty.
+func (m *x11Manager) receiveChunk() {
+	m.mu.Lock()
+	incr := m.saving != nil && m.saving.incr
+	m.mu.Unlock()
+	if !incr {
+		return
+	}
+	reply, err := xproto.GetProperty(m.conn, true, m.window, m.atoms["STASHCLIP_SAVE"], xproto.GetPropertyTypeAny, 0, maxProperty).Reply()
+	if err != nil {
+		m.finishSave(false)
+		return
+	}
+	if reply.ValueLen == 0 {
+		m.finishSave(true)
+		return
+	}
+	m.mu.Lock()
+	if m.saving != nil {
+		m.saving.data = append(m.saving.data, reply.Value...)
+	}
+	m.mu.Unlock()
+}
+
+// finishSave takes the CLIPBOARD selection over with the converted text
+// and answers the SAVE_TARGETS request.
+func (m *x11Manager) finishSave(ok bool) {
+	m.mu.Lock()
+	req := m.saving
+	m.saving = nil
+	if req != nil {
+		req.timer.Stop()
+		ok = ok && len(req.data) > 0 && utf8.Valid(req.data)
+		if ok {
+			m.kept = string(req.data)
+		}
+	}
+	m.mu.Unlock()
+	if req == nil {
+		return
+	}
+	if ok && m.own(req.ev.Time) {
+		logger.Debug("x11 clipboard saved on request", "requestor", req.ev.Requestor)
+		xproto.ChangeProperty(m.conn, xproto.PropModeReplace, req.ev.Requestor, req.property, m.atoms["NULL"], 32, 0, nil)
+	} else {
+		ok = false
+	}
+	m.notify(req.ev, req.property, ok)
+}
+
+func (m *x11Manager) replyClipboard(ev xproto.SelectionRequestEvent, property xproto.Atom) bool {
+	m.mu.Lock()
+	text, owned := m.serving, m.owned
+	m.mu.Unlock()
+	if !owned {
+		return false
+	}
+	latin1, isLatin1 := toLatin1(text)
+	switch ev.Target {
+	case m.atoms["TARGETS"]:
+		targets := []xproto.Atom{m.atoms["TARGETS"], m.atoms["UTF8_STRING"], m.atoms["TEXT"],
+			m.atoms["text/plain"], m.atoms["text/plain;charset=utf-8"]}
+		if isLatin1 {
+			targets = append(targets, m.atoms["STRING"])
+		}
+		m.setAtoms(ev.Requestor, property, targets...)
+		return true
+	case m.atoms["STRING"]:
+		// STRING is Latin-1, which cannot hold every text.
+		if !isLatin1 {
+			return false
+		}
+		m.sendText(ev.Requestor, property, ev.Target, latin1)
+		m.pastedQueued()
+		return true
+	case m.atoms["UTF8_STRING"], m.atoms["TEXT"], m.atoms["text/plain"], m.atoms["text/plain;charset=utf-8"]:
+		target := ev.Target
+		if target == m.atoms["TEXT"] {
+			target = m.atoms["UTF8_STRING"]
+		}
+		m.sendText(ev.Requestor, property, target, []byte(text))
+		m.pastedQueued()
+		return true
+	}
+	return false
+}
+
+// toLatin1 encodes text as ISO-8859-1 and reports whether it could.
+func toLatin1(text string) ([]byte, bool) {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r > 0xff {
+			return nil, false
+		}
+		out = append(out, byte(r))
+	}
+	return out, true
+}
+
+func (m *x11Manager) setAtoms(window xproto.Window, property xproto.Atom, atoms ...xproto.Atom) {
+	data := make([]byte, 4*len(atoms))
+	for i, a := range atoms {
+		xgb.Put32(data[4*i:], uint32(a))
+	}
+	xproto.ChangeProperty(m.conn, xproto.PropModeReplace, window, property, xproto.AtomAtom, 32, uint32(len(atoms)), data)
+}
+
+// sendText writes data to the requestor's property, starting an INCR
+// transfer when it does not fit in one request.
+func (m *x11Manager) sendText(window xproto.Window, property, target xproto.Atom, data []byte) {
+	if len(data) <= m.chunk {
+		xproto.ChangeProperty(m.conn, xproto.PropModeReplace, window, property, target, 8, uint32(len(data)), data)
+		return
+	}
+	xproto.ChangeWindowAttributes(m.conn, window, xproto.CwEventMask, []uint32{xproto.EventMaskPropertyChange})
+	size := make([]byte, 4)
+	xgb.Put32(size, uint32(len(data)))
+	xproto.ChangeProperty(m.conn, xproto.PropModeReplace, window, property, m.atoms["INCR"], 32, 1, size)
+	m.transfers[incrKey{window, property}] = &transfer{target: target, data: data}
+}
+
+// continueTransfer writes the next INCR chunk once the requestor deleted
+// the previous one. The last chunk is empty.
+func (m *x11Manager) continueTransfer(key incrKey) {
+	t, ok := m.transfers[key]
+	if !ok {
+		return
+	}
+	n := len(t.data)
+	if n > m.chunk {
+		n = m.chunk
+	}
+	xproto.ChangeProperty(m.conn, xproto.PropModeReplace, key.window, key.property, t.target, 8, uint32(n), t.data[:n])
+	if n == 0 {
+		delete(m.transfers, key)
+		xproto.ChangeWindowAttributes(m.conn, key.window, xproto.CwEventMask, []uint32{xproto.EventMaskNoEvent})
+		return
+	}
+	t.data = t.data[n:]
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Config holds user settings loaded from config.json.
+type Config struct {
+	Hooks         []Hook        `json:"hooks"`
+	Transform     Transform     `json:"transform"`
+	Actions       []Action      `json:"actions"`
+	Popup         Popup         `json:"popup"`
+	Merge         Merge         `json:"merge"`
+	Paste         Paste         `json:"paste"`
+	Notifications Notifications `json:"notifications"`
+	Tray          Tray          `json:"tray"`
+	Snippets      Snippets      `json:"snippets"`
+	History       History       `json:"history"`
+	// TagRules tag captured and imported entries automatically.
+	TagRules []TagRule `json:"tag_rules"`
+	// Locale overrides the language detected from LANG, such as "pt" or "en".
+	Locale string `json:"locale"`
+}
+
+// TagRule tags entries whose text matches Pattern (a regular expression)
+// and whose content kind is Kind. Either may be empty, not both.
+type TagRule struct {
+	Tag     string `json:"tag"`
+	Pattern string `json:"pattern"`
+	Kind    string `json:"kind"`
+}
+
+// History configures how captured texts are stored.
+type History struct {
+	// Dedupe is "consecutive" (the default) to skip a text equal to the
+	// latest entry, "global" to move an earlier copy to the top, or
+	// "keep-all" to store every capture.
+	Dedupe string `json:"dedupe"`
+}
+
+// Snippets configures text templates.
+type Snippets struct {
+	// Dir holds one snippet per file, named after the file without its
+	// extension. Defaults to the "snippets" directory next to config.json.
+	Dir   string    `json:"dir"`
+	Items []Snippet `json:"items"`
+}
+
+// Snippet is a text template defined in the config.
+type Snippet struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// Tray configures the daemon tray icon.
+type Tray struct {
+	Enabled bool `json:"enabled"`
+	// Entries is the number of recent entries in the menu, 10 by default.
+	Entries int `json:"entries"`
+}
+
+// Notifications configures desktop notifications.
+type Notifications struct {
+	// Events lists the notified events: "pick", "delete", "drop" and
+	// "pause".
+	// Notifications are off when empty.
+	Events []string `json:"events"`
+	// Timeout is how long a notification stays visible, 5s by default.
+	Timeout Duration `json:"timeout"`
+}
+
+// Paste configures auto-paste after an entry is chosen in the popup.
+type Paste struct {
+	Enabled bool `json:"enabled"`
+	// Keystroke is the paste shortcut, "ctrl+v" by default.
+	Keystroke string `json:"keystroke"`
+	// TerminalKeystroke is used when the focused window is a terminal,
+	// "ctrl+shift+v" by default.
+	TerminalKeystroke string `json:"terminal_keystroke"`
+	// TerminalClasses are extra window classes treated as terminals.
+	TerminalClasses []string `json:"terminal_classes"`
+	// Delay lets the target window regain focus before the keystroke.
+	Delay Duration `json:"delay"`
+}
+
+// Merge configures how several picked entries are joined.
+type Merge struct {
+	// Separator is "newline" (default), "space", "comma", "tab" or a
+	// literal string where \n and \t are expanded.
+	Separator string `json:"separator"`
+	// Save stores the merged text as a new entry.
+	Save bool `json:"save"`
+}
+
+// Action is a user-defined quick action for entries of a kind.
+type Action struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	// Kind restricts the action to one content kind; empty or "*" means any.
+	Kind string `json:"kind"`
+	// Command receives the entry text on stdin; "{text}" in arguments is
+	// replaced by the text.
+	Command []string `json:"command"`
+}
+
+// Popup configures the popup flow.
+type Popup struct {
+	// ActionStep shows the action menu after every item is chosen.
+	ActionStep bool `json:"action_step"`
+	// Provider names the backend to use, like STASHCLIP_POPUP_PROVIDER.
+	Provider string `json:"provider"`
+	// Backends are external pickers driven by an argument template.
+	Backends []PopupBackend `json:"backends"`
+}
+
+// PopupBackend describes an external picker command.
+//
+// Placeholders: {prompt} in Command; {id}, {kind}, {date}, {text},
+// {summary} and {details} in Line and ItemArgs.
+type PopupBackend struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+	// Input is "stdin" (one Line per item, the default) or "args"
+	// (ItemArgs appended to Command for each item).
+	Input    string   `json:"input"`
+	Line     string   `json:"line"`
+	ItemArgs []string `json:"item_args"`
+	// Output is a regular expression matched against stdout whose first
+	// group is the chosen ID. Defaults to a leading number.
+	Output string `json:"output"`
+	// ExitCodes maps exit codes other than 0 to popup actions
+	// (transform, menu, delete, pin, edit, copy-plain).
+	ExitCodes map[string]string `json:"exit_codes"`
+}
+
+// Transform configures transform actions.
+type Transform struct {
+	// Save stores transform results as new entries.
+	Save bool `json:"save"`
+}
+
+// Hook describes a user command run for captured entries.
+type Hook struct {
+	Name          string   `json:"name"`
+	Command       []string `json:"command"`
+	Match         Match    `json:"match"`
+	Timeout       Duration `json:"timeout"`
+	MaxConcurrent int      `json:"max_concurrent"`
+	// Filter hooks run before the entry is stored and may veto or replace it.
+	Filter bool `json:"filter"`
+}
+
+// Match restricts which entries a hook receives. Empty fields match anything.
+type Match struct {
+	Pattern string `json:"pattern"`
+	MIME    string `json:"mime"`
+	// SourceApp is known on X11 only, so it matches nothing on Wayland.
+	SourceApp string `json:"source_app"`
+}
+
+// Duration is a time.Duration encoded as a string such as "5s".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration: %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Load reads the config from the default path.
+func Load() (Config, error) {
+	return LoadPath(DefaultPath())
+}
+
+// LoadPath reads the config from path. A missing file yields the defaults.
+func LoadPath(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// DefaultPath returns the default config location.
+func DefaultPath() string {
+	if path := os.Getenv("STASHCLIP_CONFIG"); path != "" {
+		return path
+	}
+	if base := os.Getenv("XDG_CONFIG_HOME"); base != "" {
+		return filepath.Join(base, "stashclip", "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "stashclip", "config.json")
+}
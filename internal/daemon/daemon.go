@@ -1,56 +1,150 @@
 package daemon
 
 import (
-	"crypto/sha256"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"stashclip/internal/clipboard"
+	"stashclip/internal/dbusapi"
+	"stashclip/internal/hooks"
+	"stashclip/internal/i18n"
+	"stashclip/internal/logging"
+	"stashclip/internal/notify"
 	"stashclip/internal/store"
+	"stashclip/internal/tray"
 )
 
+// textMIME is the MIME type of every entry captured by the daemon.
+const textMIME = "text/plain;charset=utf-8"
+
+// Options configures optional daemon behavior.
+type Options struct {
+	Logger *slog.Logger
+	Hooks  *hooks.Runner
+	// Notifier reports dropped entries; nil disables notifications.
+	Notifier *notify.Notifier
+	// Service is the D-Bus interface, which can pause capture and is told
+	// about new entries; nil runs without a bus.
+	Service *dbusapi.Service
+	// Tray is refreshed when entries are added; nil runs without a tray.
+	Tray *tray.Tray
+}
+
 // Run starts the clipboard monitoring loop and blocks until interrupted.
-func Run(clipboardProvider clipboard.ClipboardProvider, store *store.Store) error {
+func Run(clipboardProvider clipboard.ClipboardProvider, store *store.Store, opts Options) error {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	defer opts.Hooks.Wait()
+	defer opts.Notifier.Close()
 	watcher, err := clipboard.NewEventWatcher()
 	if err != nil {
 		return err
 	}
 	defer watcher.Close()
+	// keeper serves captured text again once its source application exits.
+	keeper, _ := watcher.(clipboard.Keeper)
+	sources, _ := watcher.(clipboard.SourceReporter)
+	queue, _ := watcher.(clipboard.PasteQueue)
+	if queue != nil {
+		opts.Service.SetPasteQueue(queue)
+	}
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(sig)
 
-	var lastHash [32]byte
-	var hasHash bool
+	// The Wayland watcher reports the current clipboard when it starts;
+	// that is a re-read, not a capture, when it is already the newest
+	// entry.
+	first := true
 
+	logger.Info("daemon started", "pid", os.Getpid())
 	for {
 		select {
-		case <-sig:
+		case s := <-sig:
+			logger.Info("daemon stopping", "signal", s.String())
 			return nil
 		case err := <-watcher.Errors():
 			if err != nil {
+				logger.Error("clipboard watcher failed", "error", err)
 				return err
 			}
 		case _, ok := <-watcher.Events():
 			if !ok {
+				logger.Info("clipboard watcher closed")
 				return nil
 			}
+			if queue != nil && queue.Serving() {
+				// Reading would use up the queued text.
+				continue
+			}
 			text, err := clipboardProvider.Read()
 			if err != nil {
+				logger.Warn("cannot read clipboard", "error", err)
 				continue
 			}
 			if clipboard.ShouldIgnore(text) {
+				keep(keeper, text)
+				continue
+			}
+			if opts.Service.Paused() {
+				logger.Debug("capture paused, skipping entry")
+				continue
+			}
+			if first {
+				first = false
+				if entries := store.List(); len(entries) > 0 && entries[len(entries)-1].Text == text {
+					logger.Debug("clipboard unchanged since start")
+					keep(keeper, text)
+					continue
+				}
+			}
+
+			// Repeated texts go to the store too: its dedupe mode decides
+			// whether they count as a use of an entry or a new one.
+			ev := hooks.Event{Text: text, MIME: textMIME, SourceApp: source(sources), AddedAt: time.Now()}
+			filtered, ok := opts.Hooks.Filter(ev)
+			if !ok {
+				opts.Notifier.Send(notify.EventDrop, i18n.T("notify.drop"), i18n.T("notify.drop.body"))
 				continue
 			}
-			hash := sha256.Sum256([]byte(text))
-			if hasHash && hash == lastHash {
+			// The clipboard keeps what the application copied, even when a
+			// filter hook changed the stored entry.
+			keep(keeper, text)
+			text = filtered
+			if err := store.Reload(); err != nil {
+				logger.Warn("cannot reload store", "error", err)
+			}
+			if err := store.Add(text); err != nil {
+				logger.Error("cannot save entry", "error", err)
 				continue
 			}
-			hasHash = true
-			lastHash = hash
-			store.Add(text)
+			logger.Debug("captured entry", "bytes", len(text), logging.Content(text))
+			ev.Text = text
+			ev.ID = store.Len()
+			opts.Hooks.Notify(ev)
+			if entries := store.List(); len(entries) > 0 {
+				opts.Service.EntryAdded(len(entries), entries[len(entries)-1])
+			}
+			opts.Tray.Refresh()
 		}
 	}
 }
+
+func source(sources clipboard.SourceReporter) string {
+	if sources == nil {
+		return ""
+	}
+	return sources.Source()
+}
+
+func keep(keeper clipboard.Keeper, text string) {
+	if keeper != nil {
+		keeper.Keep(text)
+	}
+}
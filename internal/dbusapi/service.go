@@ -0,0 +1,427 @@
+// Package dbusapi exposes the daemon history on the session bus as
+// io.github.stashclip, described by io.github.stashclip.xml.
+package dbusapi
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"stashclip/internal/clipboard"
+	"stashclip/internal/i18n"
+	"stashclip/internal/logging"
+	"stashclip/internal/notify"
+	"stashclip/internal/store"
+)
+
+const (
+	// Name is the well-known bus name owned by the daemon.
+	Name = "io.github.stashclip"
+	// Path is the object path of the history.
+	Path = dbus.ObjectPath("/io/github/stashclip")
+	// Interface is the interface of the history object.
+	Interface = "io.github.stashclip"
+
+	propertiesInterface = "org.freedesktop.DBus.Properties"
+)
+
+//go:embed io.github.stashclip.xml
+var introspection string
+
+// ErrNameTaken reports that another process owns Name.
+var ErrNameTaken = errors.New("dbus name " + Name + " already owned")
+
+// ErrNotRunning reports that no daemon owns Name.
+var ErrNotRunning = errors.New("daemon not running")
+
+// Entry is an entry as sent over the bus, signature (usxsb).
+type Entry struct {
+	ID      uint32
+	Text    string
+	AddedAt int64
+	Kind    string
+	Pinned  bool
+}
+
+// Options configures optional service behavior.
+type Options struct {
+	Logger   *slog.Logger
+	Notifier *notify.Notifier
+}
+
+// Service serves the history of a store on the session bus. Its methods
+// are nil-safe, so the daemon runs the same without a bus.
+type Service struct {
+	conn      *dbus.Conn
+	store     *store.Store
+	clipboard clipboard.ClipboardProvider
+	logger    *slog.Logger
+	notifier  *notify.Notifier
+
+	mu      sync.Mutex
+	paused  bool
+	onPause []func(paused bool)
+	queue   clipboard.PasteQueue
+}
+
+// Start connects to the session bus, exports the history object and
+// claims Name. It returns ErrNameTaken when another daemon runs.
+func Start(st *store.Store, cb clipboard.ClipboardProvider, opts Options) (*Service, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	s := &Service{conn: conn, store: st, clipboard: cb, logger: logger, notifier: opts.Notifier}
+	exports := []struct {
+		v     any
+		iface string
+	}{
+		{methods{s}, Interface},
+		{properties{s}, propertiesInterface},
+		{introspectable(introspection), "org.freedesktop.DBus.Introspectable"},
+	}
+	for _, e := range exports {
+		if err := conn.Export(e.v, Path, e.iface); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	reply, err := conn.RequestName(Name, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return nil, ErrNameTaken
+	}
+	st.SetOnRemove(s.entriesRemoved)
+	logger.Info("dbus service started", "name", Name)
+	return s, nil
+}
+
+// Call invokes a method of the running daemon.
+func Call(method string, args ...any) error {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.Object(Name, Path).Call(Interface+"."+method, 0, args...).Err
+	var derr dbus.Error
+	if errors.As(err, &derr) && derr.Name == "org.freedesktop.DBus.Error.ServiceUnknown" {
+		return ErrNotRunning
+	}
+	return err
+}
+
+// Close releases the bus name and connection.
+func (s *Service) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// Paused reports whether capture is paused.
+func (s *Service) Paused() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// SetPaused pauses or resumes capture and announces the change.
+func (s *Service) SetPaused(paused bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	changed := s.paused != paused
+	s.paused = paused
+	listeners := s.onPause
+	s.mu.Unlock()
+	if !changed {
+		return
+	}
+	for _, f := range listeners {
+		f(paused)
+	}
+	s.logger.Info("capture pause changed", "paused", paused)
+	s.emit("PauseChanged", paused)
+	_ = s.conn.Emit(Path, propertiesInterface+".PropertiesChanged", Interface,
+		map[string]dbus.Variant{"Paused": dbus.MakeVariant(paused)}, []string{})
+	if paused {
+		s.notifier.Send(notify.EventPause, i18n.T("notify.pause"), "")
+	} else {
+		s.notifier.Send(notify.EventPause, i18n.T("notify.resume"), "")
+	}
+}
+
+// OnPauseChanged calls f after capture is paused or resumed.
+func (s *Service) OnPauseChanged(f func(paused bool)) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onPause = append(s.onPause, f)
+}
+
+// SetPasteQueue enables the Queue method, served by the clipboard watcher.
+func (s *Service) SetPasteQueue(q clipboard.PasteQueue) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = q
+}
+
+func (s *Service) pasteQueue() (clipboard.PasteQueue, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queue == nil {
+		return nil, dbus.NewError(Interface+".Error.NotSupported", []any{"paste queue is not available in this session"})
+	}
+	return s.queue, nil
+}
+
+// EntryAdded announces the entry with the given 1-based id.
+func (s *Service) EntryAdded(id int, e store.Entry) {
+	if s == nil {
+		return
+	}
+	s.emit("EntryAdded", toEntry(id, e))
+}
+
+// entriesRemoved announces entries removed from the store, by any path.
+func (s *Service) entriesRemoved(indexes []int) {
+	for _, i := range indexes {
+		s.emit("EntryRemoved", uint32(i+1))
+	}
+}
+
+func (s *Service) emit(name string, values ...any) {
+	if err := s.conn.Emit(Path, Interface+"."+name, values...); err != nil {
+		s.logger.Warn("cannot emit dbus signal", "signal", name, "error", err)
+	}
+}
+
+// entries reloads the store, since popups change it from other processes.
+func (s *Service) entries() ([]store.Entry, *dbus.Error) {
+	if err := s.store.Reload(); err != nil {
+		return nil, failed(err)
+	}
+	return s.store.List(), nil
+}
+
+func toEntry(id int, e store.Entry) Entry {
+	return Entry{
+		ID:      uint32(id),
+		Text:    e.Text,
+		AddedAt: e.AddedAt.Unix(),
+		Kind:    e.Classification().Label(),
+		Pinned:  e.Pinned,
+	}
+}
+
+// methods holds the exported io.github.stashclip methods.
+type methods struct {
+	s *Service
+}
+
+func (m methods) List() ([]Entry, *dbus.Error) {
+	return m.Search("")
+}
+
+func (m methods) Get(id uint32) (Entry, *dbus.Error) {
+	entries, derr := m.s.entries()
+	if derr != nil {
+		return Entry{}, derr
+	}
+	if err := checkID(id, entries); err != nil {
+		return Entry{}, err
+	}
+	return toEntry(int(id), entries[id-1]), nil
+}
+
+func (m methods) Pick(id uint32) *dbus.Error {
+	entry, derr := m.entry(id)
+	if derr != nil {
+		return derr
+	}
+	if err := clipboard.MarkIgnored(entry.Text); err != nil {
+		return failed(err)
+	}
+	if err := m.s.clipboard.Write(entry.Text); err != nil {
+		return failed(err)
+	}
+	if err := m.s.store.TouchEntry(entry); err != nil {
+		return failed(err)
+	}
+	// The global dedupe mode moves a picked entry to the top: its removal
+	// from where it was is announced by the store, its new place here.
+	if entries := m.s.store.List(); int(id) != len(entries) && sameEntry(entries[len(entries)-1], entry) {
+		m.s.EntryAdded(len(entries), entries[len(entries)-1])
+	}
+	return nil
+}
+
+// Delete, Pin and Pick find the entry again under the store lock, since the
+// daemon may add or move entries after the id was checked.
+func (m methods) Delete(id uint32) *dbus.Error {
+	entry, derr := m.entry(id)
+	if derr != nil {
+		return derr
+	}
+	if _, err := m.s.store.DeleteEntry(entry); err != nil {
+		return failed(err)
+	}
+	return nil
+}
+
+func (m methods) Pin(id uint32) (bool, *dbus.Error) {
+	entry, derr := m.entry(id)
+	if derr != nil {
+		return false, derr
+	}
+	pinned, err := m.s.store.TogglePinEntry(entry)
+	if err != nil {
+		return false, failed(err)
+	}
+	return pinned, nil
+}
+
+func (m methods) Reload() *dbus.Error {
+	_, derr := m.s.entries()
+	return derr
+}
+
+func (m methods) entry(id uint32) (store.Entry, *dbus.Error) {
+	entries, derr := m.s.entries()
+	if derr != nil {
+		return store.Entry{}, derr
+	}
+	if err := checkID(id, entries); err != nil {
+		return store.Entry{}, err
+	}
+	return entries[id-1], nil
+}
+
+func (m methods) Search(query string) ([]Entry, *dbus.Error) {
+	entries, derr := m.s.entries()
+	if derr != nil {
+		return nil, derr
+	}
+	query = strings.ToLower(query)
+	out := []Entry{}
+	for i, e := range entries {
+		if query == "" || strings.Contains(strings.ToLower(e.Text), query) {
+			out = append(out, toEntry(i+1, e))
+		}
+	}
+	return out, nil
+}
+
+func (m methods) Pause(paused bool) *dbus.Error {
+	m.s.SetPaused(paused)
+	return nil
+}
+
+func (m methods) Queue(ids []uint32) *dbus.Error {
+	entries, derr := m.s.entries()
+	if derr != nil {
+		return derr
+	}
+	if len(ids) == 0 {
+		return dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []any{"no entries to queue"})
+	}
+	texts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if err := checkID(id, entries); err != nil {
+			return err
+		}
+		texts = append(texts, entries[id-1].Text)
+	}
+	q, derr := m.s.pasteQueue()
+	if derr != nil {
+		return derr
+	}
+	progress := func(left int) { m.s.emit("QueueChanged", uint32(left)) }
+	if err := q.StartQueue(texts, progress); err != nil {
+		return failed(err)
+	}
+	m.s.logger.Info("paste queue started", "entries", len(texts))
+	m.s.emit("QueueChanged", uint32(len(texts)))
+	return nil
+}
+
+func (m methods) StopQueue() *dbus.Error {
+	q, derr := m.s.pasteQueue()
+	if derr != nil {
+		return derr
+	}
+	q.StopQueue()
+	return nil
+}
+
+// properties implements org.freedesktop.DBus.Properties for Paused.
+type properties struct {
+	s *Service
+}
+
+func (p properties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	all, err := p.GetAll(iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	v, ok := all[name]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.UnknownProperty", []any{name})
+	}
+	return v, nil
+}
+
+func (p properties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	if iface != Interface {
+		return nil, dbus.NewError("org.freedesktop.DBus.Error.UnknownInterface", []any{iface})
+	}
+	return map[string]dbus.Variant{"Paused": dbus.MakeVariant(p.s.Paused())}, nil
+}
+
+func (p properties) Set(iface, name string, _ dbus.Variant) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.PropertyReadOnly", []any{name})
+}
+
+type introspectable string
+
+func (i introspectable) Introspect() (string, *dbus.Error) {
+	return string(i), nil
+}
+
+func sameEntry(a, b store.Entry) bool {
+	return a.Text == b.Text && a.AddedAt.Equal(b.AddedAt)
+}
+
+func checkID(id uint32, entries []store.Entry) *dbus.Error {
+	if id < 1 || int(id) > len(entries) {
+		return dbus.NewError(Interface+".Error.NotFound", []any{fmt.Sprintf("no entry %d", id)})
+	}
+	return nil
+}
+
+func failed(err error) *dbus.Error {
+	return dbus.MakeFailedError(err)
+}
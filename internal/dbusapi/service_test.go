@@ -0,0 +1,226 @@
+package dbusapi
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"stashclip/internal/store"
+	"stashclip/internal/testbus"
+)
+
+// fakeClipboard records what is written to the clipboard.
+type fakeClipboard struct {
+	mu   sync.Mutex
+	text string
+}
+
+func (c *fakeClipboard) Read() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.text, nil
+}
+
+func (c *fakeClipboard) Write(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.text = text
+	return nil
+}
+
+// client calls the service and receives its signals.
+type client struct {
+	obj     dbus.BusObject
+	signals chan *dbus.Signal
+}
+
+func startService(t *testing.T, texts ...string) (*Service, *store.Store, *fakeClipboard, *client) {
+	t.Helper()
+	testbus.Start(t)
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	st, err := store.NewWithPath(store.DefaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range texts {
+		if err := st.Add(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cb := &fakeClipboard{}
+	s, err := Start(st, cb, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if err := conn.AddMatchSignal(dbus.WithMatchInterface(Interface)); err != nil {
+		t.Fatal(err)
+	}
+	c := &client{obj: conn.Object(Name, Path), signals: make(chan *dbus.Signal, 16)}
+	conn.Signal(c.signals)
+	return s, st, cb, c
+}
+
+func (c *client) call(t *testing.T, method string, out any, args ...any) {
+	t.Helper()
+	call := c.obj.Call(Interface+"."+method, 0, args...)
+	if call.Err != nil {
+		t.Fatalf("%s: %v", method, call.Err)
+	}
+	if out != nil {
+		if err := call.Store(out); err != nil {
+			t.Fatalf("%s reply: %v", method, err)
+		}
+	}
+}
+
+func (c *client) signal(t *testing.T, name string) []any {
+	t.Helper()
+	for {
+		select {
+		case sig := <-c.signals:
+			if sig.Name == Interface+"."+name {
+				return sig.Body
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %s signal", name)
+			return nil
+		}
+	}
+}
+
+func (c *client) removed(t *testing.T, want ...uint32) {
+	t.Helper()
+	for _, id := range want {
+		if body := c.signal(t, "EntryRemoved"); body[0].(uint32) != id {
+			t.Errorf("EntryRemoved(%v), want %d", body[0], id)
+		}
+	}
+}
+
+func TestListGet(t *testing.T) {
+	_, _, _, c := startService(t, "one", "two")
+
+	var entries []Entry
+	c.call(t, "List", &entries)
+	if len(entries) != 2 || entries[0].ID != 1 || entries[0].Text != "one" || entries[1].Text != "two" {
+		t.Fatalf("List = %+v", entries)
+	}
+	var e Entry
+	c.call(t, "Get", &e, uint32(2))
+	if e.ID != 2 || e.Text != "two" || e.Kind == "" {
+		t.Errorf("Get(2) = %+v", e)
+	}
+	err := c.obj.Call(Interface+".Get", 0, uint32(3)).Err
+	var derr dbus.Error
+	if !errors.As(err, &derr) || derr.Name != Interface+".Error.NotFound" {
+		t.Errorf("Get(3) error = %v", err)
+	}
+}
+
+func TestDeleteSignals(t *testing.T) {
+	_, st, _, c := startService(t, "one", "two", "three")
+
+	c.call(t, "Delete", nil, uint32(2))
+	c.removed(t, 2)
+	if got := texts(st.List()); got != "one,three" {
+		t.Errorf("after Delete(2): %s", got)
+	}
+
+	// Removals through the store, not the bus, are signalled too.
+	if err := st.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	c.removed(t, 1)
+	if err := st.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	c.removed(t, 1)
+}
+
+func TestReloadSignalsRemovals(t *testing.T) {
+	_, st, _, c := startService(t, "one", "two", "three")
+
+	// Another process deletes entries and asks the daemon to reload.
+	popup, err := store.NewWithPath(store.DefaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := popup.Delete(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := popup.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	c.call(t, "Reload", nil)
+	c.removed(t, 3, 1)
+	if got := texts(st.List()); got != "two" {
+		t.Errorf("after Reload: %s", got)
+	}
+}
+
+func TestPinAndPick(t *testing.T) {
+	_, st, cb, c := startService(t, "one", "two", "three")
+	st.SetDedupe(store.DedupeGlobal)
+
+	var pinned bool
+	c.call(t, "Pin", &pinned, uint32(1))
+	if !pinned || !st.List()[0].Pinned {
+		t.Errorf("Pin(1) = %v, entries %+v", pinned, st.List())
+	}
+
+	c.call(t, "Pick", nil, uint32(2))
+	if text, _ := cb.Read(); text != "two" {
+		t.Errorf("clipboard = %q, want two", text)
+	}
+	entries := st.List()
+	if got := texts(entries); got != "one,three,two" || entries[2].Uses != 2 {
+		t.Errorf("after Pick(2): %s, uses %d", got, entries[2].Uses)
+	}
+	c.removed(t, 2)
+	if body := c.signal(t, "EntryAdded"); body[0].([]any)[0].(uint32) != 3 {
+		t.Errorf("EntryAdded(%v), want id 3", body[0])
+	}
+}
+
+func TestPause(t *testing.T) {
+	s, _, _, c := startService(t)
+
+	changed := make(chan bool, 2)
+	s.OnPauseChanged(func(paused bool) { changed <- paused })
+	c.call(t, "Pause", nil, true)
+	if body := c.signal(t, "PauseChanged"); body[0] != true {
+		t.Errorf("PauseChanged(%v), want true", body[0])
+	}
+	if !s.Paused() || len(changed) != 1 || !<-changed {
+		t.Errorf("Paused() = %v after Pause(true)", s.Paused())
+	}
+	v, err := c.obj.GetProperty(Interface + ".Paused")
+	if err != nil || v.Value() != true {
+		t.Errorf("Paused property = %v, %v", v, err)
+	}
+	c.call(t, "Pause", nil, false)
+	if body := c.signal(t, "PauseChanged"); body[0] != false {
+		t.Errorf("PauseChanged(%v), want false", body[0])
+	}
+}
+
+func texts(entries []store.Entry) string {
+	out := ""
+	for i, e := range entries {
+		if i > 0 {
+			out += ","
+		}
+		out += e.Text
+	}
+	return out
+}
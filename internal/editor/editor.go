@@ -0,0 +1,88 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// terminals are tried in order when no terminal is attached, with the
+// arguments that make them run a command and wait for it.
+var terminals = [][]string{
+	{"x-terminal-emulator", "-e"},
+	{"foot"},
+	{"alacritty", "-e"},
+	{"kitty"},
+	{"wezterm", "start", "--"},
+	{"konsole", "-e"},
+	{"gnome-terminal", "--wait", "--"},
+	{"xfce4-terminal", "--disable-server", "-x"},
+	{"xterm", "-e"},
+}
+
+// Edit opens text in $VISUAL or $EDITOR (vi by default) and returns the
+// saved result. Without an attached terminal the editor runs in $TERMINAL
+// or the first terminal emulator found.
+func Edit(text string) (string, error) {
+	f, err := os.CreateTemp("", "stashclip-edit-*.txt")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	if _, err := f.WriteString(text); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	argv := append(editorCommand(), path)
+	if err := run(argv); err != nil {
+		return "", fmt.Errorf("editor: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
+
+func run(argv []string) error {
+	if tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0); err == nil {
+		defer tty.Close()
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = tty, tty, tty
+		return cmd.Run()
+	}
+	term, err := terminalCommand()
+	if err != nil {
+		return err
+	}
+	full := append(term, argv...)
+	return exec.Command(full[0], full[1:]...).Run()
+}
+
+func terminalCommand() ([]string, error) {
+	if t := strings.Fields(os.Getenv("TERMINAL")); len(t) > 0 {
+		return append(t, "-e"), nil
+	}
+	for _, t := range terminals {
+		if _, err := exec.LookPath(t[0]); err == nil {
+			return append([]string(nil), t...), nil
+		}
+	}
+	return nil, errors.New("no terminal emulator found (set $TERMINAL)")
+}
@@ -0,0 +1,155 @@
+package exchange
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"stashclip/internal/store"
+)
+
+// archiveVersion is the archive layout written by this version. Archives
+// with a newer version are refused rather than half read.
+const archiveVersion = 1
+
+const (
+	manifestName = "manifest.json"
+	entriesName  = "entries.jsonl"
+	blobDir      = "blobs"
+)
+
+// manifest describes an archive.
+type manifest struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Entries    int       `json:"entries"`
+}
+
+// writeArchive writes the manifest, then one blob per distinct text named
+// after its SHA-256, then the entry metadata pointing at the blobs.
+func writeArchive(w io.Writer, entries []store.Entry) error {
+	tw := tar.NewWriter(w)
+	now := time.Now()
+	put := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest{Format: "stashclip", Version: archiveVersion, ExportedAt: now, Entries: len(entries)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := put(manifestName, data); err != nil {
+		return err
+	}
+
+	var meta bytes.Buffer
+	enc := json.NewEncoder(&meta)
+	written := map[string]bool{}
+	for _, e := range entries {
+		sum := sha256.Sum256([]byte(e.Text))
+		blob := path.Join(blobDir, hex.EncodeToString(sum[:]))
+		if !written[blob] {
+			if err := put(blob, []byte(e.Text)); err != nil {
+				return err
+			}
+			written[blob] = true
+		}
+		rec := newRecord(e)
+		rec.Text, rec.Blob = "", blob
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	if err := put(entriesName, meta.Bytes()); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// readArchive reads a plain or gzipped archive.
+func readArchive(r io.Reader) ([]store.Entry, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	} else {
+		r = br
+	}
+
+	var man *manifest
+	var records []record
+	blobs := map[string]string{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		switch {
+		case name == manifestName:
+			man = &manifest{}
+			if err := json.NewDecoder(tr).Decode(man); err != nil {
+				return nil, fmt.Errorf("%s: %w", manifestName, err)
+			}
+			if man.Format != "stashclip" {
+				return nil, fmt.Errorf("not a stashclip archive: %q", man.Format)
+			}
+			if man.Version > archiveVersion {
+				return nil, fmt.Errorf("archive version %d is newer than supported (%d)", man.Version, archiveVersion)
+			}
+		case name == entriesName:
+			records, err = decodeRecords(tr)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", entriesName, err)
+			}
+		case path.Dir(name) == blobDir:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			blobs[name] = string(data)
+		}
+	}
+	if man == nil {
+		return nil, fmt.Errorf("missing %s", manifestName)
+	}
+
+	entries := make([]store.Entry, 0, len(records))
+	for i, rec := range records {
+		if rec.Blob != "" {
+			text, ok := blobs[path.Clean(rec.Blob)]
+			if !ok {
+				return nil, fmt.Errorf("entry %d: missing blob %s", i+1, rec.Blob)
+			}
+			rec.Text = text
+		}
+		entries = append(entries, rec.entry())
+	}
+	return entries, nil
+}
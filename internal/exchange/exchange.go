@@ -0,0 +1,120 @@
+// Package exchange writes the history in export formats and reads it back,
+// along with the histories of other clipboard managers.
+package exchange
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"stashclip/internal/store"
+)
+
+// Format is an export or import format.
+type Format string
+
+const (
+	// JSONL is one JSON record per line.
+	JSONL Format = "jsonl"
+	// CSV is a table with a header row.
+	CSV Format = "csv"
+	// Archive is the native tar archive with a manifest, metadata and one
+	// blob per distinct text.
+	Archive Format = "archive"
+	// CopyQ is a NUL-separated dump of CopyQ items, newest first.
+	CopyQ Format = "copyq"
+	// Clipman is the history file of clipman, a JSON array oldest first.
+	Clipman Format = "clipman"
+	// Cliphist is the output of "cliphist list", newest first.
+	Cliphist Format = "cliphist"
+)
+
+// ExportFormats lists the formats Export writes.
+var ExportFormats = []Format{JSONL, CSV, Archive}
+
+// ImportFormats lists the formats Import reads.
+var ImportFormats = []Format{JSONL, CSV, Archive, CopyQ, Clipman, Cliphist}
+
+// ParseFormat returns the format with the given name.
+func ParseFormat(name string) (Format, bool) {
+	for _, f := range ImportFormats {
+		if string(f) == name {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// FormatFor guesses the format of a file from its extension.
+func FormatFor(path string) (Format, bool) {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.HasSuffix(name, ".jsonl"), strings.HasSuffix(name, ".ndjson"):
+		return JSONL, true
+	case strings.HasSuffix(name, ".csv"):
+		return CSV, true
+	case strings.HasSuffix(name, ".tar"), strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return Archive, true
+	case name == "clipman.json":
+		return Clipman, true
+	}
+	return "", false
+}
+
+// Compressed reports whether an archive at path should be gzipped.
+func Compressed(path string) bool {
+	name := strings.ToLower(path)
+	return strings.HasSuffix(name, ".gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// Export writes entries to w.
+func Export(w io.Writer, format Format, entries []store.Entry) error {
+	switch format {
+	case JSONL:
+		return writeJSONL(w, entries)
+	case CSV:
+		return writeCSV(w, entries)
+	case Archive:
+		return writeArchive(w, entries)
+	}
+	return fmt.Errorf("cannot export to %s", format)
+}
+
+// Import reads entries from r, oldest first. Formats without timestamps
+// get times just before now, in their original order. skipped counts the
+// items left out because only a shortened preview of them was found.
+func Import(r io.Reader, format Format) (entries []store.Entry, skipped int, err error) {
+	switch format {
+	case JSONL:
+		entries, err = readJSONL(r)
+	case CSV:
+		entries, err = readCSV(r)
+	case Archive:
+		entries, err = readArchive(r)
+	case CopyQ:
+		entries, err = readCopyQ(r)
+	case Clipman:
+		entries, err = readClipman(r)
+	case Cliphist:
+		return readCliphist(r)
+	default:
+		err = fmt.Errorf("cannot import from %s", format)
+	}
+	return entries, 0, err
+}
+
+// stamp turns texts, oldest first, into entries a millisecond apart ending
+// now.
+func stamp(texts []string) []store.Entry {
+	now := time.Now()
+	entries := make([]store.Entry, 0, len(texts))
+	for i, text := range texts {
+		entries = append(entries, store.Entry{
+			Text:    text,
+			AddedAt: now.Add(-time.Duration(len(texts)-1-i) * time.Millisecond),
+		})
+	}
+	return entries
+}
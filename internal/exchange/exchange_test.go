@@ -0,0 +1,166 @@
+package exchange
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"stashclip/internal/store"
+)
+
+func sample() []store.Entry {
+	at := time.Date(2024, 5, 31, 9, 30, 0, 123456789, time.UTC)
+	return []store.Entry{
+		{Text: "plain", AddedAt: at, LastUsed: at, Uses: 1},
+		{Text: "multi\nline, with \"quotes\"", AddedAt: at.Add(time.Minute), LastUsed: at.Add(time.Hour), Uses: 3, Pinned: true, Tags: []string{"sql", "work"}},
+		{Text: "ação 🚀\t", AddedAt: at.Add(2 * time.Minute), LastUsed: at.Add(2 * time.Minute), Uses: 1, Tags: []string{"todo"}},
+		// The archive stores one blob for both copies.
+		{Text: "plain", AddedAt: at.Add(3 * time.Minute), LastUsed: at.Add(3 * time.Minute), Uses: 2},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range ExportFormats {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Export(&buf, format, sample()); err != nil {
+				t.Fatal(err)
+			}
+			got, skipped, err := Import(&buf, format)
+			if err != nil || skipped != 0 {
+				t.Fatalf("Import: %v (skipped %d)", err, skipped)
+			}
+			sameEntries(t, got, sample())
+		})
+	}
+}
+
+func TestGzipArchive(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := Export(zw, Archive, sample()); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := Import(&buf, Archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameEntries(t, got, sample())
+}
+
+func TestCSVColumnsByName(t *testing.T) {
+	data := "Text,Pinned,Tags\nfirst,true,\"Work, #SQL\"\nsecond,,\n"
+	got, _, err := Import(strings.NewReader(data), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts(got) != "first|second" || !got[0].Pinned || !reflect.DeepEqual(got[0].Tags, []string{"sql", "work"}) {
+		t.Errorf("Import = %+v", got)
+	}
+	if !got[0].AddedAt.Before(got[1].AddedAt) {
+		t.Errorf("rows without added_at are not stamped in order")
+	}
+	if _, _, err := Import(strings.NewReader("uses\n1\n"), CSV); err == nil {
+		t.Error("a table without a text column was accepted")
+	}
+}
+
+func TestManagers(t *testing.T) {
+	// Without cliphist only the previews are read.
+	t.Setenv("PATH", t.TempDir())
+	tests := []struct {
+		format  Format
+		file    string
+		want    string
+		skipped int
+	}{
+		{CopyQ, "copyq.txt", "oldest|multi\nline|newest", 0},
+		{Clipman, "clipman.json", "first|second\nline|{\"json\": 1}", 0},
+		{Cliphist, "cliphist.txt", "plain|https://example.com/a", 1},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			got, skipped := importFixture(t, tt.file, tt.format)
+			if texts(got) != tt.want || skipped != tt.skipped {
+				t.Errorf("Import = %q, skipped %d; want %q, skipped %d", texts(got), skipped, tt.want, tt.skipped)
+			}
+			for i := 1; i < len(got); i++ {
+				if !got[i-1].AddedAt.Before(got[i].AddedAt) {
+					t.Errorf("entries not stamped oldest first: %v", got)
+				}
+			}
+		})
+	}
+}
+
+// fakeCliphist decodes the ids of testdata/cliphist.txt. Id 1 decodes to
+// an item that does not match its preview, as with a list copied from
+// another machine.
+const fakeCliphist = `#!/bin/sh
+[ "$1" = decode ] || exit 1
+IFS= read -r line
+case "${line%%	*}" in
+4) printf 'https://example.com/a' ;;
+2) printf 'long text that was shortened and goes on\n  over two lines\n' ;;
+1) printf 'something else' ;;
+*) exit 1 ;;
+esac
+`
+
+func TestCliphistDecode(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "cliphist"), []byte(fakeCliphist), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	got, skipped := importFixture(t, "cliphist.txt", Cliphist)
+	want := "plain|long text that was shortened and goes on\n  over two lines\n|https://example.com/a"
+	if texts(got) != want || skipped != 0 {
+		t.Errorf("Import = %q, skipped %d; want %q", texts(got), skipped, want)
+	}
+}
+
+func importFixture(t *testing.T, name string, format Format) ([]store.Entry, int) {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	entries, skipped, err := Import(f, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries, skipped
+}
+
+func sameEntries(t *testing.T, got, want []store.Entry) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Text != w.Text || !g.AddedAt.Equal(w.AddedAt) || !g.LastUsed.Equal(w.LastUsed) ||
+			g.Uses != w.Uses || g.Pinned != w.Pinned || !reflect.DeepEqual(g.Tags, w.Tags) {
+			t.Errorf("entry %d = %+v, want %+v", i+1, g, w)
+		}
+	}
+}
+
+func texts(entries []store.Entry) string {
+	out := make([]string, 0, len(entries))
+	for _, e := range entries {
+		out = append(out, e.Text)
+	}
+	return strings.Join(out, "|")
+}
@@ -0,0 +1,112 @@
+package exchange
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"stashclip/internal/store"
+)
+
+// readCopyQ reads items separated by NUL bytes, newest first, as printed
+// by a CopyQ script such as
+//
+//	for (var i = 0; i < size(); ++i) { print(read(i)); print("\0") }
+func readCopyQ(r io.Reader) ([]store.Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var texts []string
+	for _, item := range bytes.Split(data, []byte{0}) {
+		if len(item) > 0 {
+			texts = append(texts, string(item))
+		}
+	}
+	reverse(texts)
+	return stamp(texts), nil
+}
+
+// readClipman reads clipman's history file, a JSON array of texts oldest
+// first.
+func readClipman(r io.Reader) ([]store.Entry, error) {
+	var texts []string
+	if err := json.NewDecoder(r).Decode(&texts); err != nil {
+		return nil, err
+	}
+	return stamp(texts), nil
+}
+
+// cliphistBinary matches the placeholder cliphist lists for images and
+// other binary items.
+var cliphistBinary = regexp.MustCompile(`^\[\[ binary data .* \]\]$`)
+
+// cliphistMore ends the previews cliphist shortens to its preview width.
+const cliphistMore = "…"
+
+// readCliphist reads "cliphist list" output: an id, a tab and the item,
+// newest first. The list only has previews, with whitespace collapsed and
+// long items shortened, so each line is decoded with "cliphist decode"
+// when cliphist is installed. Without it, or when the decoded item does
+// not match the preview (a list from another machine), the preview is
+// kept unless it was shortened; skipped counts those.
+func readCliphist(r io.Reader) (entries []store.Entry, skipped int, err error) {
+	_, lookErr := exec.LookPath("cliphist")
+	decode := lookErr == nil
+	var texts []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		_, preview, ok := strings.Cut(line, "\t")
+		if !ok || preview == "" || cliphistBinary.MatchString(preview) {
+			continue
+		}
+		if decode {
+			if text, err := decodeCliphist(line); err == nil && previewOf(text, preview) {
+				texts = append(texts, text)
+				continue
+			}
+		}
+		if strings.HasSuffix(preview, cliphistMore) {
+			skipped++
+			continue
+		}
+		texts = append(texts, preview)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, 0, err
+	}
+	reverse(texts)
+	return stamp(texts), skipped, nil
+}
+
+// decodeCliphist returns the full item of a "cliphist list" line.
+func decodeCliphist(line string) (string, error) {
+	cmd := exec.Command("cliphist", "decode")
+	cmd.Stdin = strings.NewReader(line + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// previewOf reports whether cliphist would list text as preview.
+func previewOf(text, preview string) bool {
+	collapsed := strings.Join(strings.Fields(text), " ")
+	if short, ok := strings.CutSuffix(preview, cliphistMore); ok {
+		return strings.HasPrefix(collapsed, short)
+	}
+	return collapsed == preview
+}
+
+func reverse(texts []string) {
+	for i, j := 0, len(texts)-1; i < j; i, j = i+1, j-1 {
+		texts[i], texts[j] = texts[j], texts[i]
+	}
+}
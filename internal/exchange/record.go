@@ -0,0 +1,196 @@
+package exchange
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"stashclip/internal/store"
+	"stashclip/internal/tags"
+)
+
+// record is an entry as written to JSON Lines and to the archive metadata.
+// Archives store the text in a blob instead.
+type record struct {
+	Text     string    `json:"text,omitempty"`
+	Blob     string    `json:"blob,omitempty"`
+	AddedAt  time.Time `json:"added_at"`
+	LastUsed time.Time `json:"last_used"`
+	Uses     int       `json:"uses,omitempty"`
+	Kind     string    `json:"kind,omitempty"`
+	Language string    `json:"language,omitempty"`
+	Pinned   bool      `json:"pinned,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+}
+
+func newRecord(e store.Entry) record {
+	return record{
+		Text:     e.Text,
+		AddedAt:  e.AddedAt,
+		LastUsed: e.LastUsed,
+		Uses:     e.Uses,
+		Kind:     string(e.Kind),
+		Language: e.Language,
+		Pinned:   e.Pinned,
+		Tags:     e.Tags,
+	}
+}
+
+// entry converts the record back. The kind is detected again on import, so
+// only the text, times, use count, pin and tags are kept.
+func (r record) entry() store.Entry {
+	return store.Entry{
+		Text:     r.Text,
+		AddedAt:  r.AddedAt,
+		LastUsed: r.LastUsed,
+		Uses:     r.Uses,
+		Pinned:   r.Pinned,
+		Tags:     tags.Normalize(r.Tags),
+	}
+}
+
+func writeJSONL(w io.Writer, entries []store.Entry) error {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(newRecord(e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readJSONL(r io.Reader) ([]store.Entry, error) {
+	records, err := decodeRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]store.Entry, 0, len(records))
+	for _, rec := range records {
+		entries = append(entries, rec.entry())
+	}
+	return entries, nil
+}
+
+func decodeRecords(r io.Reader) ([]record, error) {
+	var records []record
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for line := 1; ; line++ {
+		var rec record
+		if err := dec.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			return nil, fmt.Errorf("record %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+var csvHeader = []string{"added_at", "last_used", "uses", "kind", "pinned", "tags", "text"}
+
+func writeCSV(w io.Writer, entries []store.Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		row := []string{
+			e.AddedAt.Format(time.RFC3339Nano),
+			e.LastUsed.Format(time.RFC3339Nano),
+			strconv.Itoa(e.Uses),
+			e.Classification().Label(),
+			strconv.FormatBool(e.Pinned),
+			strings.Join(e.Tags, ","),
+			e.Text,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readCSV reads the columns it knows by header name, so tables edited in a
+// spreadsheet may drop or reorder them. Only "text" is required.
+func readCSV(r io.Reader) ([]store.Entry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	textCol, ok := columns["text"]
+	if !ok {
+		return nil, errors.New(`missing "text" column`)
+	}
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+	var texts []string
+	var rows [][]string
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if textCol >= len(row) {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("line %d: missing text", line)
+		}
+		texts = append(texts, row[textCol])
+		rows = append(rows, row)
+	}
+	entries := stamp(texts)
+	for i, row := range rows {
+		if v := field(row, "added_at"); v != "" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", i+1, err)
+			}
+			entries[i].AddedAt = t
+		}
+		if v := field(row, "last_used"); v != "" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", i+1, err)
+			}
+			entries[i].LastUsed = t
+		}
+		if v := field(row, "uses"); v != "" {
+			uses, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid uses value: %s", i+1, v)
+			}
+			entries[i].Uses = uses
+		}
+		if v := field(row, "pinned"); v != "" {
+			pinned, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: invalid pinned value: %s", i+1, v)
+			}
+			entries[i].Pinned = pinned
+		}
+		entries[i].Tags = tags.Parse(field(row, "tags"))
+	}
+	return entries, nil
+}
@@ -0,0 +1,228 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"stashclip/internal/config"
+	"stashclip/internal/logging"
+)
+
+const (
+	defaultTimeout       = 10 * time.Second
+	defaultMaxConcurrent = 4
+)
+
+// Event is the entry payload sent to hooks as JSON on stdin.
+type Event struct {
+	ID        int       `json:"id,omitempty"`
+	Text      string    `json:"text"`
+	MIME      string    `json:"mime"`
+	SourceApp string    `json:"source_app,omitempty"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// Response is the optional JSON a filter hook prints on stdout.
+type Response struct {
+	Veto bool    `json:"veto"`
+	Text *string `json:"text"`
+}
+
+type hook struct {
+	cfg     config.Hook
+	pattern *regexp.Regexp
+	timeout time.Duration
+	slots   chan struct{}
+}
+
+// Runner executes configured hooks for captured entries.
+type Runner struct {
+	filters []*hook
+	notify  []*hook
+	logger  *slog.Logger
+	wg      sync.WaitGroup
+}
+
+// NewRunner validates hook configs and returns a runner for them.
+func NewRunner(cfgs []config.Hook, logger *slog.Logger) (*Runner, error) {
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	r := &Runner{logger: logger}
+	for i, cfg := range cfgs {
+		if cfg.Name == "" {
+			cfg.Name = "hook-" + strconv.Itoa(i+1)
+		}
+		if len(cfg.Command) == 0 {
+			return nil, fmt.Errorf("hook %s: command is required", cfg.Name)
+		}
+		h := &hook{cfg: cfg, timeout: time.Duration(cfg.Timeout)}
+		if h.timeout <= 0 {
+			h.timeout = defaultTimeout
+		}
+		if cfg.Match.Pattern != "" {
+			re, err := regexp.Compile(cfg.Match.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("hook %s: %w", cfg.Name, err)
+			}
+			h.pattern = re
+		}
+		for _, glob := range []string{cfg.Match.MIME, cfg.Match.SourceApp} {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("hook %s: invalid match %q: %w", cfg.Name, glob, err)
+			}
+		}
+		limit := cfg.MaxConcurrent
+		if limit <= 0 {
+			limit = defaultMaxConcurrent
+		}
+		h.slots = make(chan struct{}, limit)
+		if cfg.Filter {
+			r.filters = append(r.filters, h)
+		} else {
+			r.notify = append(r.notify, h)
+		}
+	}
+	return r, nil
+}
+
+// Filter runs filter hooks in order before an entry is stored.
+// It returns the possibly replaced text and false if a hook vetoed storage.
+func (r *Runner) Filter(ev Event) (string, bool) {
+	if r == nil {
+		return ev.Text, true
+	}
+	for _, h := range r.filters {
+		if !h.matches(ev) {
+			continue
+		}
+		h.slots <- struct{}{}
+		out, err := r.run(h, ev)
+		<-h.slots
+		if err != nil {
+			r.logger.Warn("filter hook failed", "hook", h.cfg.Name, "error", err)
+			continue
+		}
+		if len(bytes.TrimSpace(out)) == 0 {
+			continue
+		}
+		var resp Response
+		if err := json.Unmarshal(out, &resp); err != nil {
+			r.logger.Warn("filter hook returned invalid response", "hook", h.cfg.Name, "error", err)
+			continue
+		}
+		if resp.Veto {
+			r.logger.Info("entry vetoed by hook", "hook", h.cfg.Name)
+			return "", false
+		}
+		if resp.Text != nil {
+			r.logger.Debug("entry replaced by hook", "hook", h.cfg.Name, logging.Content(*resp.Text))
+			ev.Text = *resp.Text
+		}
+	}
+	return ev.Text, true
+}
+
+// Notify starts post-capture hooks in the background.
+// Hooks already running at their concurrency limit skip the event.
+func (r *Runner) Notify(ev Event) {
+	if r == nil {
+		return
+	}
+	for _, h := range r.notify {
+		if !h.matches(ev) {
+			continue
+		}
+		select {
+		case h.slots <- struct{}{}:
+		default:
+			r.logger.Warn("hook busy, skipping event", "hook", h.cfg.Name, "max_concurrent", cap(h.slots))
+			continue
+		}
+		r.wg.Add(1)
+		go func(h *hook) {
+			defer r.wg.Done()
+			defer func() { <-h.slots }()
+			if _, err := r.run(h, ev); err != nil {
+				r.logger.Warn("hook failed", "hook", h.cfg.Name, "error", err)
+			}
+		}(h)
+	}
+}
+
+// Wait blocks until background hooks finish.
+func (r *Runner) Wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
+
+func (r *Runner) run(h *hook, ev Event) ([]byte, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.cfg.Command[0], h.cfg.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"STASHCLIP_HOOK="+h.cfg.Name,
+		"STASHCLIP_ENTRY_ID="+entryID(ev.ID),
+		"STASHCLIP_MIME_TYPE="+ev.MIME,
+		"STASHCLIP_SOURCE_APP="+ev.SourceApp,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	out, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", h.timeout)
+	}
+	if err != nil {
+		if msg := bytes.TrimSpace(stderr.Bytes()); len(msg) > 0 {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	r.logger.Debug("hook finished", "hook", h.cfg.Name, "duration", time.Since(start))
+	return out, nil
+}
+
+func (h *hook) matches(ev Event) bool {
+	if h.pattern != nil && !h.pattern.MatchString(ev.Text) {
+		return false
+	}
+	if h.cfg.Match.MIME != "" {
+		if ok, _ := path.Match(h.cfg.Match.MIME, ev.MIME); !ok {
+			return false
+		}
+	}
+	if h.cfg.Match.SourceApp != "" {
+		if ok, _ := path.Match(h.cfg.Match.SourceApp, ev.SourceApp); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func entryID(id int) string {
+	if id <= 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
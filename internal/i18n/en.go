@@ -0,0 +1,192 @@
+package i18n
+
+var en = map[string]string{
+	// Popup list.
+	"popup.select":          "Select an item to copy",
+	"popup.select.short":    "Select an item",
+	"popup.rofi.keys":       "(Alt+d delete · Alt+p pin · Alt+e edit · Alt+c no newline · Alt+t transform · Alt+a actions · Alt+s snippets)",
+	"popup.button.copy":     "Copy",
+	"popup.button.plain":    "Copy without newline",
+	"popup.button.edit":     "Edit",
+	"popup.button.pin":      "Pin",
+	"popup.button.delete":   "Delete",
+	"popup.button.menu":     "Actions",
+	"popup.button.trans":    "Transform",
+	"popup.button.snippets": "Snippets",
+	"popup.button.close":    "Close",
+	"popup.button.back":     "Back",
+	"popup.button.ok":       "OK",
+	"popup.column.age":      "Age",
+	"popup.column.kind":     "Kind",
+	"popup.column.lines":    "Lines",
+	"popup.column.size":     "Size",
+	"popup.column.text":     "Text",
+	"popup.column.action":   "Action",
+
+	// Entry details.
+	"age.now":     "just now",
+	"age.minutes": "%d min ago",
+	"age.hours":   "%d h ago",
+	"age.day":     "1 day ago",
+	"age.days":    "%d days ago",
+	"age.date":    "Jan 2, 2006",
+	"lines.one":   "%d line",
+	"lines.many":  "%d lines",
+	"uses":        "used %d times",
+
+	// Action menu.
+	"menu.choose.action":    "Choose an action",
+	"menu.choose.transform": "Choose a transform",
+	"menu.choose.snippet":   "Choose a snippet",
+	"menu.copy":             "Copy",
+	"menu.plain":            "Copy without trailing newline",
+	"menu.edit":             "Edit before copying",
+	"menu.transform":        "Transform…",
+	"menu.pin":              "Pin",
+	"menu.unpin":            "Unpin",
+	"menu.pin.toggle":       "Pin/unpin",
+	"menu.delete":           "Delete",
+
+	// Terminal picker.
+	"tui.help.select": "Enter copy · Tab mark · Alt-c no newline · Alt-e edit · Alt-d delete · Alt-p pin · Alt-t transform · Alt-a actions · Alt-s snippets · Esc quit",
+	"tui.help.ask":    "Enter confirm · Esc cancel",
+	"tui.help.choose": "Enter choose · Esc back",
+	"tui.marked":      "(%d marked)",
+
+	// Quick actions.
+	"action.open.url":  "Open in browser",
+	"action.open.path": "Open file",
+	"action.reveal":    "Show in file manager",
+	"action.compose":   "Write email",
+	"action.color":     "Preview color",
+
+	// Transforms.
+	"transform.trim":                "Trim surrounding whitespace",
+	"transform.collapse-whitespace": "Collapse runs of whitespace into one space",
+	"transform.upper":               "UPPER CASE",
+	"transform.lower":               "lower case",
+	"transform.title":               "Title Case",
+	"transform.base64-encode":       "Base64 encode",
+	"transform.base64-decode":       "Base64 decode",
+	"transform.url-encode":          "URL query encode",
+	"transform.url-decode":          "URL query decode",
+	"transform.json-pretty":         "Pretty-print JSON",
+	"transform.json-minify":         "Minify JSON",
+	"transform.xml-pretty":          "Pretty-print XML",
+	"transform.xml-minify":          "Minify XML",
+	"transform.strip-tracking":      "Remove tracking parameters from URLs",
+
+	// Notifications.
+	"notify.pick":        "Copied to clipboard",
+	"notify.delete":      "Entry deleted",
+	"notify.delete.many": "%d entries deleted",
+	"notify.undo":        "Undo",
+	"notify.drop":        "Copied text not saved",
+	"notify.drop.body":   "A filter hook rejected it.",
+	"notify.pause":       "Clipboard capture paused",
+	"notify.resume":      "Clipboard capture resumed",
+
+	// Tray.
+	"tray.empty":        "(history is empty)",
+	"tray.pause":        "Pause capture",
+	"tray.popup":        "Open popup",
+	"tray.clear":        "Clear history",
+	"tray.title.paused": "Stashclip (paused)",
+	"tray.tooltip":      "%d entries",
+
+	// Command line.
+	"cli.unsupported": "unsupported command: use Ctrl+Alt+A to open stashclip popup",
+	"cli.usage": `Open popup and choose an item to copy again.
+Recommended usage: global shortcut Ctrl+Alt+A -> stashclip-popup
+
+KIND is one of: %s
+pick copies the latest entry, or joins several ids with SEP (newline, space, comma, tab or text).
+--paste pastes the chosen item into the previously focused window (also "paste" in the config).
+transform without a name lists the available transforms.
+The result is copied to the clipboard; --save also stores it as a new entry.
+action without an action name lists the actions available for the entry.
+tui opens the terminal picker; --print writes the choice to stdout instead of the clipboard.
+set stores the clipboard (default), a text or history entry N in a named register; get copies it back.
+push stashes the clipboard on a stack; pop restores the top and removes it.
+queue has the daemon put the entries on the clipboard in order, moving to the next after each paste.
+snippet without a name lists the snippets; popup --snippets opens them in the popup.
+tag adds comma-separated tags to an entry and removes those prefixed with "-"; tags lists them.
+export writes jsonl, csv or archive (.tar, .tar.gz); import also reads copyq, clipman and cliphist.
+store check reports damage in the history file, store repair keeps its readable entries and
+store restore replaces it with a daily backup (by date, such as 2024-05-31; none lists them).
+
+Flags (before the command):
+  -v, --verbose          log debug messages to stderr
+  --log-level=LEVEL      debug, info, warn or error (default warn)
+  --log-format=FORMAT    text or json (default text)
+  --log-contents         include clipboard text in logs (redacted by default)`,
+	"cli.daemon.already":  "daemon already running (pid %d)",
+	"cli.daemon.started":  "daemon started (pid %d)",
+	"cli.daemon.stopped":  "daemon stopped (pid %d)",
+	"cli.daemon.running":  "daemon running (pid %d)",
+	"cli.daemon.idle":     "daemon not running",
+	"cli.import.done":     "imported %d entries (skipped %d duplicates, %d empty)",
+	"cli.import.dry":      "would import %d entries (skip %d duplicates, %d empty)",
+	"cli.import.trimmed":  "%d oldest entries dropped by the history limit",
+	"cli.import.short":    "skipped %d items with only a shortened preview (install cliphist to decode them)",
+	"cli.store.recovered": "history file was damaged: kept %d readable entries, moved the original to %s",
+	"cli.store.ok":        "%s: schema version %d, %d entries",
+	"cli.store.invalid":   "%d entries without text (store repair drops them)",
+	"cli.store.damaged":   "%s is damaged: %v",
+	"cli.store.salvage":   "%d entries can be recovered with store repair",
+	"cli.store.backups":   "backups:",
+	"cli.store.nobackups": "no backups yet",
+	"cli.store.healthy":   "nothing to repair (%d entries)",
+	"cli.store.repaired":  "kept %d entries, dropped %d; the original was moved to %s",
+	"cli.store.restored":  "restored %s",
+	"cli.store.replaced":  "the previous history was moved to %s",
+
+	// Command line errors.
+	"err.prefix":           "%s error: ",
+	"err.range":            "index out of range: %d",
+	"err.index":            "invalid index: %s",
+	"err.args":             "too many arguments",
+	"err.empty":            "no entries available",
+	"err.id":               "missing entry id",
+	"err.ids":              "missing entry ids",
+	"err.arg":              "unknown argument: %s",
+	"err.action":           "unknown action: %s",
+	"err.transform":        "unknown transform: %s",
+	"err.value":            "missing value for %s",
+	"err.query":            "missing query",
+	"err.register.name":    "missing register name",
+	"err.register.unknown": "no register named %q",
+	"err.register.one":     "expected one register name",
+	"err.set.empty":        "nothing to store",
+	"err.clipboard.empty":  "clipboard is empty",
+	"err.stack.empty":      "stack is empty",
+	"err.snippet.none":     "no snippets found (add files to %s)",
+	"err.snippet.unknown":  "unknown snippet: %s",
+	"err.format.guess":     "cannot tell the format of %s (use --format)",
+	"err.format.export":    "cannot export to %s (use one of: %s)",
+	"err.format.unknown":   "unknown format: %s (use one of: %s)",
+	"err.import.file":      "expected one file (or - for stdin)",
+	"err.import.read":      "%s: %w",
+	"err.store.action":     "missing action (check, repair or restore)",
+	"err.store.check":      "check failed",
+	"err.store.path":       "cannot locate the history file",
+	"err.kind":             "unknown kind: %s (use one of: %s)",
+	"err.tag":              "invalid tag: %s",
+	"err.pid":              "invalid pid file",
+	"err.daemon.stop":      "process %d did not stop",
+	"err.daemon.idle":      "not running",
+	"err.daemon.args":      "invalid arguments",
+	"err.daemon.timeout":   "failed to stop pid %d after timeout: %w",
+	"err.daemon.start":     "failed to stay running (check %s)",
+	"err.dedupe":           "unknown dedupe mode: %s (use consecutive, global or keep-all)",
+
+	// Popup and terminal picker errors.
+	"err.popup.none":        "no supported popup backend found (install one of: %s)",
+	"err.popup.unavailable": "popup backend not available: %s",
+	"err.popup.options":     "no options available",
+	"err.popup.selection":   "invalid selection",
+	"err.popup.ask":         "%s cannot ask for text",
+	"err.tui.tty":           "no terminal available",
+	"err.tui.notty":         "not a terminal",
+	"err.tui.raw":           "cannot enter raw mode",
+}
@@ -0,0 +1,116 @@
+// Package i18n translates user-facing strings.
+//
+// Messages are looked up by key in the catalog of the current locale,
+// falling back to English and then to the key itself.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is used when no shipped locale matches the environment.
+const DefaultLocale = "en"
+
+var catalogs = map[string]map[string]string{
+	"en": en,
+	"pt": pt,
+}
+
+var (
+	mu      sync.RWMutex
+	current = Detect()
+)
+
+// Detect returns the shipped locale matching LC_ALL, LC_MESSAGES or LANG,
+// in that order, or DefaultLocale.
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if locale, ok := match(value); ok {
+			return locale
+		}
+		// The first variable set decides, as in setlocale(3).
+		return DefaultLocale
+	}
+	return DefaultLocale
+}
+
+// SetLocale selects the catalog for a locale name such as "pt", "pt_BR" or
+// "pt_BR.UTF-8". It reports false and keeps the current locale when no
+// catalog matches.
+func SetLocale(name string) bool {
+	locale, ok := match(name)
+	if !ok {
+		return false
+	}
+	mu.Lock()
+	current = locale
+	mu.Unlock()
+	return true
+}
+
+// Locale returns the current locale.
+func Locale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Locales returns the shipped locales.
+func Locales() []string {
+	names := make([]string, 0, len(catalogs))
+	for name := range catalogs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// T returns the message for key in the current locale, formatted with args.
+func T(key string, args ...any) string {
+	msg, ok := Lookup(key)
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Lookup returns the unformatted message for key in the current locale or
+// in English.
+func Lookup(key string) (string, bool) {
+	if msg, ok := catalogs[Locale()][key]; ok {
+		return msg, true
+	}
+	msg, ok := catalogs[DefaultLocale][key]
+	return msg, ok
+}
+
+// match maps a POSIX locale name to a shipped catalog.
+func match(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	if name == "c" || name == "posix" {
+		return DefaultLocale, true
+	}
+	if _, ok := catalogs[name]; ok {
+		return name, true
+	}
+	if i := strings.IndexAny(name, "_-"); i >= 0 {
+		if _, ok := catalogs[name[:i]]; ok {
+			return name[:i], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+)
+
+// verbs matches the formatting verbs of a message.
+var verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+
+func TestCatalogKeys(t *testing.T) {
+	for name, catalog := range catalogs {
+		if name == DefaultLocale {
+			continue
+		}
+		for _, key := range missing(catalogs[DefaultLocale], catalog) {
+			t.Errorf("%s: missing key %q", name, key)
+		}
+		for _, key := range missing(catalog, catalogs[DefaultLocale]) {
+			t.Errorf("%s: key %q is not in %s", name, key, DefaultLocale)
+		}
+	}
+}
+
+func TestCatalogVerbs(t *testing.T) {
+	for name, catalog := range catalogs {
+		for key, msg := range catalog {
+			want := verbs.FindAllString(catalogs[DefaultLocale][key], -1)
+			got := verbs.FindAllString(msg, -1)
+			if len(got) != len(want) {
+				t.Errorf("%s: %q has verbs %v, want %v", name, key, got, want)
+				continue
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s: %q has verbs %v, want %v", name, key, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+// missing returns the keys of want that have no message in have.
+func missing(want, have map[string]string) []string {
+	var keys []string
+	for key := range want {
+		if _, ok := have[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,192 @@
+package i18n
+
+var pt = map[string]string{
+	// Popup list.
+	"popup.select":          "Selecione um item para copiar",
+	"popup.select.short":    "Selecione um item",
+	"popup.rofi.keys":       "(Alt+d apagar · Alt+p fixar · Alt+e editar · Alt+c sem quebra · Alt+t transformar · Alt+a ações · Alt+s snippets)",
+	"popup.button.copy":     "Copiar",
+	"popup.button.plain":    "Copiar sem quebra de linha",
+	"popup.button.edit":     "Editar",
+	"popup.button.pin":      "Fixar",
+	"popup.button.delete":   "Apagar",
+	"popup.button.menu":     "Ações",
+	"popup.button.trans":    "Transformar",
+	"popup.button.snippets": "Snippets",
+	"popup.button.close":    "Fechar",
+	"popup.button.back":     "Voltar",
+	"popup.button.ok":       "OK",
+	"popup.column.age":      "Idade",
+	"popup.column.kind":     "Tipo",
+	"popup.column.lines":    "Linhas",
+	"popup.column.size":     "Tamanho",
+	"popup.column.text":     "Texto",
+	"popup.column.action":   "Ação",
+
+	// Entry details.
+	"age.now":     "agora",
+	"age.minutes": "há %d min",
+	"age.hours":   "há %d h",
+	"age.day":     "há 1 dia",
+	"age.days":    "há %d dias",
+	"age.date":    "02/01/2006",
+	"lines.one":   "%d linha",
+	"lines.many":  "%d linhas",
+	"uses":        "usado %d vezes",
+
+	// Action menu.
+	"menu.choose.action":    "Escolha uma ação",
+	"menu.choose.transform": "Escolha uma transformação",
+	"menu.choose.snippet":   "Escolha um snippet",
+	"menu.copy":             "Copiar",
+	"menu.plain":            "Copiar sem quebra de linha",
+	"menu.edit":             "Editar antes de copiar",
+	"menu.transform":        "Transformar…",
+	"menu.pin":              "Fixar",
+	"menu.unpin":            "Desafixar",
+	"menu.pin.toggle":       "Fixar/desafixar",
+	"menu.delete":           "Apagar",
+
+	// Terminal picker.
+	"tui.help.select": "Enter copiar · Tab marcar · Alt-c sem quebra · Alt-e editar · Alt-d apagar · Alt-p fixar · Alt-t transformar · Alt-a ações · Alt-s snippets · Esc sair",
+	"tui.help.ask":    "Enter confirmar · Esc cancelar",
+	"tui.help.choose": "Enter escolher · Esc voltar",
+	"tui.marked":      "(%d marcados)",
+
+	// Quick actions.
+	"action.open.url":  "Abrir no navegador",
+	"action.open.path": "Abrir arquivo",
+	"action.reveal":    "Mostrar no gerenciador de arquivos",
+	"action.compose":   "Escrever e-mail",
+	"action.color":     "Visualizar cor",
+
+	// Transforms.
+	"transform.trim":                "Remover espaços nas pontas",
+	"transform.collapse-whitespace": "Juntar espaços repetidos em um só",
+	"transform.upper":               "MAIÚSCULAS",
+	"transform.lower":               "minúsculas",
+	"transform.title":               "Iniciais Maiúsculas",
+	"transform.base64-encode":       "Codificar em Base64",
+	"transform.base64-decode":       "Decodificar Base64",
+	"transform.url-encode":          "Codificar para URL",
+	"transform.url-decode":          "Decodificar URL",
+	"transform.json-pretty":         "Formatar JSON",
+	"transform.json-minify":         "Compactar JSON",
+	"transform.xml-pretty":          "Formatar XML",
+	"transform.xml-minify":          "Compactar XML",
+	"transform.strip-tracking":      "Remover parâmetros de rastreamento de URLs",
+
+	// Notifications.
+	"notify.pick":        "Copiado para a área de transferência",
+	"notify.delete":      "Item apagado",
+	"notify.delete.many": "%d itens apagados",
+	"notify.undo":        "Desfazer",
+	"notify.drop":        "Texto copiado não foi salvo",
+	"notify.drop.body":   "Um hook de filtro o rejeitou.",
+	"notify.pause":       "Captura da área de transferência pausada",
+	"notify.resume":      "Captura da área de transferência retomada",
+
+	// Tray.
+	"tray.empty":        "(histórico vazio)",
+	"tray.pause":        "Pausar captura",
+	"tray.popup":        "Abrir popup",
+	"tray.clear":        "Limpar histórico",
+	"tray.title.paused": "Stashclip (pausado)",
+	"tray.tooltip":      "%d itens",
+
+	// Command line.
+	"cli.unsupported": "comando não suportado: use Ctrl+Alt+A para abrir o popup do stashclip",
+	"cli.usage": `Abre o popup para escolher um item e copiá-lo de novo.
+Uso recomendado: atalho global Ctrl+Alt+A -> stashclip-popup
+
+KIND é um de: %s
+pick copia o último item, ou junta vários ids com SEP (newline, space, comma, tab ou texto).
+--paste cola o item escolhido na janela que estava em foco (também "paste" no config).
+transform sem nome lista as transformações disponíveis.
+O resultado é copiado para a área de transferência; --save também o salva como novo item.
+action sem nome de ação lista as ações disponíveis para o item.
+tui abre o seletor no terminal; --print escreve a escolha no stdout em vez da área de transferência.
+set guarda a área de transferência (padrão), um texto ou o item N do histórico em um registrador; get o copia de volta.
+push empilha a área de transferência; pop restaura o topo da pilha e o remove.
+queue faz o daemon colocar os itens na área de transferência em ordem, passando ao próximo a cada colagem.
+snippet sem nome lista os snippets; popup --snippets os abre no popup.
+tag adiciona tags separadas por vírgula a um item e remove as prefixadas com "-"; tags lista as tags.
+export grava jsonl, csv ou archive (.tar, .tar.gz); import também lê copyq, clipman e cliphist.
+store check aponta danos no arquivo do histórico, store repair mantém os itens legíveis e
+store restore o substitui por um backup diário (pela data, como 2024-05-31; sem ela, lista os backups).
+
+Flags (antes do comando):
+  -v, --verbose          registra mensagens de debug no stderr
+  --log-level=LEVEL      debug, info, warn ou error (padrão warn)
+  --log-format=FORMAT    text ou json (padrão text)
+  --log-contents         inclui o texto copiado nos logs (omitido por padrão)`,
+	"cli.daemon.already":  "daemon já está rodando (pid %d)",
+	"cli.daemon.started":  "daemon iniciado (pid %d)",
+	"cli.daemon.stopped":  "daemon parado (pid %d)",
+	"cli.daemon.running":  "daemon rodando (pid %d)",
+	"cli.daemon.idle":     "daemon não está rodando",
+	"cli.import.done":     "%d itens importados (%d duplicados e %d vazios ignorados)",
+	"cli.import.dry":      "%d itens seriam importados (%d duplicados e %d vazios ignorados)",
+	"cli.import.trimmed":  "%d itens mais antigos removidos pelo limite do histórico",
+	"cli.import.short":    "%d itens ignorados por terem só uma prévia encurtada (instale o cliphist para decodificá-los)",
+	"cli.store.recovered": "arquivo do histórico estava danificado: %d itens legíveis mantidos, original movido para %s",
+	"cli.store.ok":        "%s: versão do formato %d, %d itens",
+	"cli.store.invalid":   "%d itens sem texto (store repair os remove)",
+	"cli.store.damaged":   "%s está danificado: %v",
+	"cli.store.salvage":   "%d itens podem ser recuperados com store repair",
+	"cli.store.backups":   "backups:",
+	"cli.store.nobackups": "nenhum backup ainda",
+	"cli.store.healthy":   "nada a reparar (%d itens)",
+	"cli.store.repaired":  "%d itens mantidos, %d removidos; o original foi movido para %s",
+	"cli.store.restored":  "%s restaurado",
+	"cli.store.replaced":  "o histórico anterior foi movido para %s",
+
+	// Command line errors.
+	"err.prefix":           "erro (%s): ",
+	"err.range":            "índice fora do intervalo: %d",
+	"err.index":            "índice inválido: %s",
+	"err.args":             "argumentos demais",
+	"err.empty":            "nenhum item disponível",
+	"err.id":               "falta o id do item",
+	"err.ids":              "faltam os ids dos itens",
+	"err.arg":              "argumento desconhecido: %s",
+	"err.action":           "ação desconhecida: %s",
+	"err.transform":        "transformação desconhecida: %s",
+	"err.value":            "falta o valor de %s",
+	"err.query":            "falta o texto da busca",
+	"err.register.name":    "falta o nome do registrador",
+	"err.register.unknown": "nenhum registrador chamado %q",
+	"err.register.one":     "informe um nome de registrador",
+	"err.set.empty":        "nada para guardar",
+	"err.clipboard.empty":  "a área de transferência está vazia",
+	"err.stack.empty":      "a pilha está vazia",
+	"err.snippet.none":     "nenhum snippet encontrado (adicione arquivos em %s)",
+	"err.snippet.unknown":  "snippet desconhecido: %s",
+	"err.format.guess":     "não dá para saber o formato de %s (use --format)",
+	"err.format.export":    "não dá para exportar para %s (use um de: %s)",
+	"err.format.unknown":   "formato desconhecido: %s (use um de: %s)",
+	"err.import.file":      "informe um arquivo (ou - para stdin)",
+	"err.import.read":      "%s: %w",
+	"err.store.action":     "falta a ação (check, repair ou restore)",
+	"err.store.check":      "a verificação falhou",
+	"err.store.path":       "não foi possível localizar o arquivo do histórico",
+	"err.kind":             "tipo desconhecido: %s (use um de: %s)",
+	"err.tag":              "tag inválida: %s",
+	"err.pid":              "arquivo de pid inválido",
+	"err.daemon.stop":      "o processo %d não parou",
+	"err.daemon.idle":      "não está rodando",
+	"err.daemon.args":      "argumentos inválidos",
+	"err.daemon.timeout":   "não foi possível parar o pid %d a tempo: %w",
+	"err.daemon.start":     "não continuou rodando (veja %s)",
+	"err.dedupe":           "modo de dedupe desconhecido: %s (use consecutive, global ou keep-all)",
+
+	// Popup and terminal picker errors.
+	"err.popup.none":        "nenhum backend de popup suportado encontrado (instale um de: %s)",
+	"err.popup.unavailable": "backend de popup indisponível: %s",
+	"err.popup.options":     "nenhuma opção disponível",
+	"err.popup.selection":   "seleção inválida",
+	"err.popup.ask":         "%s não consegue pedir texto",
+	"err.tui.tty":           "nenhum terminal disponível",
+	"err.tui.notty":         "não é um terminal",
+	"err.tui.raw":           "não foi possível entrar no modo raw",
+}
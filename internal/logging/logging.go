@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// ContentKey is the attribute key used for clipboard contents.
+// Values logged under this key are redacted unless Options.ShowContents is set.
+const ContentKey = "content"
+
+// Options configures the logger returned by New.
+type Options struct {
+	Level        slog.Level
+	Format       string
+	ShowContents bool
+	Output       io.Writer
+}
+
+// DefaultOptions returns options derived from the STASHCLIP_LOG_* environment variables.
+func DefaultOptions() (Options, error) {
+	opts := Options{Level: slog.LevelWarn, Format: "text", Output: os.Stderr}
+	if v := strings.TrimSpace(os.Getenv("STASHCLIP_LOG_LEVEL")); v != "" {
+		level, err := ParseLevel(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.Level = level
+	}
+	if v := strings.TrimSpace(os.Getenv("STASHCLIP_LOG_FORMAT")); v != "" {
+		format, err := ParseFormat(v)
+		if err != nil {
+			return opts, err
+		}
+		opts.Format = format
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("STASHCLIP_LOG_CONTENTS"))) {
+	case "1", "true", "yes":
+		opts.ShowContents = true
+	}
+	return opts, nil
+}
+
+// ParseLevel parses a level name (debug, info, warn, error).
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid log level: %s", s)
+	}
+}
+
+// ParseFormat parses a log format name (text, json).
+func ParseFormat(s string) (string, error) {
+	switch f := strings.ToLower(strings.TrimSpace(s)); f {
+	case "text", "json":
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid log format: %s", s)
+	}
+}
+
+// New returns a logger configured by opts.
+func New(opts Options) *slog.Logger {
+	out := opts.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	if !opts.ShowContents {
+		handlerOpts.ReplaceAttr = redactContent
+	}
+	if opts.Format == "json" {
+		return slog.New(slog.NewJSONHandler(out, handlerOpts))
+	}
+	return slog.New(slog.NewTextHandler(out, handlerOpts))
+}
+
+// Discard returns a logger that drops every record.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// Content returns an attribute carrying clipboard text.
+func Content(text string) slog.Attr {
+	return slog.String(ContentKey, text)
+}
+
+func redactContent(_ []string, a slog.Attr) slog.Attr {
+	if a.Key != ContentKey {
+		return a
+	}
+	return slog.String(ContentKey, fmt.Sprintf("[redacted %d bytes]", len(a.Value.String())))
+}
@@ -0,0 +1,249 @@
+// Package notify sends desktop notifications through the
+// org.freedesktop.Notifications D-Bus service.
+package notify
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"stashclip/internal/config"
+	"stashclip/internal/logging"
+)
+
+const (
+	busName   = "org.freedesktop.Notifications"
+	busPath   = dbus.ObjectPath("/org/freedesktop/Notifications")
+	busMethod = busName + ".Notify"
+
+	appName        = "stashclip"
+	appIcon        = "edit-paste"
+	defaultTimeout = 5 * time.Second
+)
+
+var errUnavailable = errors.New("notification service unavailable")
+
+// Events that can be notified.
+const (
+	// EventPick is sent when an entry is copied from the popup or by pick.
+	EventPick = "pick"
+	// EventDelete is sent when an entry is deleted; it offers an undo button.
+	EventDelete = "delete"
+	// EventDrop is sent when the daemon drops a copied text, such as a
+	// filter hook veto.
+	EventDrop = "drop"
+	// EventPause is sent when capture is paused or resumed.
+	EventPause = "pause"
+)
+
+// Action is a notification button.
+type Action struct {
+	Key   string
+	Label string
+}
+
+// Notifier sends notifications for the configured events. A nil Notifier
+// or one without events sends nothing. The bus connection is opened on
+// first use; when it fails, notifications are disabled with a warning.
+type Notifier struct {
+	events  map[string]bool
+	timeout time.Duration
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	conn    *dbus.Conn
+	failed  bool
+	signals chan *dbus.Signal
+	waiters map[uint32]chan string
+	pending sync.WaitGroup
+}
+
+// New returns a Notifier for cfg.
+func New(cfg config.Notifications, logger *slog.Logger) *Notifier {
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	n := &Notifier{
+		events:  map[string]bool{},
+		timeout: time.Duration(cfg.Timeout),
+		logger:  logger,
+		waiters: map[uint32]chan string{},
+	}
+	if n.timeout <= 0 {
+		n.timeout = defaultTimeout
+	}
+	for _, e := range cfg.Events {
+		n.events[e] = true
+	}
+	return n
+}
+
+// Enabled reports whether event is notified.
+func (n *Notifier) Enabled(event string) bool {
+	return n != nil && n.events[event]
+}
+
+// Send shows a notification for event if it is enabled.
+func (n *Notifier) Send(event, summary, body string) {
+	if !n.Enabled(event) {
+		return
+	}
+	if _, err := n.notify(summary, body, nil); err != nil {
+		n.logger.Warn("notification failed", "event", event, "error", err)
+	}
+}
+
+// SendWithActions shows a notification with buttons and calls onAction in
+// the background with the key of the button the user clicks. Nothing is
+// called when the notification expires or is dismissed. Wait blocks until
+// every such notification is resolved.
+func (n *Notifier) SendWithActions(event, summary, body string, actions []Action, onAction func(key string)) {
+	if !n.Enabled(event) {
+		return
+	}
+	if err := n.subscribe(); err != nil {
+		n.logger.Warn("notification failed", "event", event, "error", err)
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	id, err := n.notifyLocked(summary, body, actions)
+	if err != nil {
+		n.logger.Warn("notification failed", "event", event, "error", err)
+		return
+	}
+	ch := make(chan string, 1)
+	n.waiters[id] = ch
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		select {
+		case key := <-ch:
+			if key != "" {
+				onAction(key)
+			}
+		case <-time.After(n.timeout + time.Second):
+			// Some servers never report expiry; stop waiting.
+		}
+		n.mu.Lock()
+		delete(n.waiters, id)
+		n.mu.Unlock()
+	}()
+}
+
+// Wait blocks until notifications with actions are clicked, closed or expired.
+func (n *Notifier) Wait() {
+	if n == nil {
+		return
+	}
+	n.pending.Wait()
+}
+
+// Close releases the bus connection.
+func (n *Notifier) Close() error {
+	if n == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
+
+func (n *Notifier) notify(summary, body string, actions []Action) (uint32, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.notifyLocked(summary, body, actions)
+}
+
+func (n *Notifier) notifyLocked(summary, body string, actions []Action) (uint32, error) {
+	conn, err := n.connLocked()
+	if err != nil {
+		return 0, err
+	}
+	flat := make([]string, 0, 2*len(actions))
+	for _, a := range actions {
+		flat = append(flat, a.Key, a.Label)
+	}
+	hints := map[string]dbus.Variant{}
+	var id uint32
+	call := conn.Object(busName, busPath).Call(busMethod, 0,
+		appName, uint32(0), appIcon, summary, body, flat, hints, int32(n.timeout/time.Millisecond))
+	if err := call.Store(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// connLocked opens the session bus connection once.
+func (n *Notifier) connLocked() (*dbus.Conn, error) {
+	if n.conn != nil {
+		return n.conn, nil
+	}
+	if n.failed {
+		return nil, errUnavailable
+	}
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		n.failed = true
+		return nil, err
+	}
+	n.conn = conn
+	return conn, nil
+}
+
+// subscribe listens for ActionInvoked and NotificationClosed signals.
+func (n *Notifier) subscribe() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.signals != nil {
+		return nil
+	}
+	conn, err := n.connLocked()
+	if err != nil {
+		return err
+	}
+	if err := conn.AddMatchSignal(dbus.WithMatchInterface(busName), dbus.WithMatchObjectPath(busPath)); err != nil {
+		return err
+	}
+	n.signals = make(chan *dbus.Signal, 16)
+	conn.Signal(n.signals)
+	go n.dispatch(n.signals)
+	return nil
+}
+
+func (n *Notifier) dispatch(signals <-chan *dbus.Signal) {
+	for sig := range signals {
+		if len(sig.Body) < 2 {
+			continue
+		}
+		id, ok := sig.Body[0].(uint32)
+		if !ok {
+			continue
+		}
+		var key string
+		switch sig.Name {
+		case busName + ".ActionInvoked":
+			key, _ = sig.Body[1].(string)
+		case busName + ".NotificationClosed":
+		default:
+			continue
+		}
+		n.mu.Lock()
+		ch := n.waiters[id]
+		n.mu.Unlock()
+		if ch != nil {
+			select {
+			case ch <- key:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"stashclip/internal/config"
+	"stashclip/internal/testbus"
+)
+
+// call is a Notify call received by the fake server.
+type call struct {
+	App     string
+	Summary string
+	Body    string
+	Actions []string
+	Timeout int32
+}
+
+// server is a fake notification server.
+type server struct {
+	conn  *dbus.Conn
+	calls chan call
+}
+
+func (s *server) Notify(app string, replaces uint32, icon, summary, body string, actions []string,
+	hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	s.calls <- call{App: app, Summary: summary, Body: body, Actions: actions, Timeout: timeout}
+	return 7, nil
+}
+
+func startServer(t *testing.T) *server {
+	t.Helper()
+	testbus.Start(t)
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	s := &server{conn: conn, calls: make(chan call, 4)}
+	if err := conn.Export(s, busPath, busName); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := conn.RequestName(busName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("requesting %s: %v", busName, err)
+	}
+	return s
+}
+
+func (s *server) next(t *testing.T) call {
+	t.Helper()
+	select {
+	case c := <-s.calls:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("no Notify call")
+		return call{}
+	}
+}
+
+func TestSend(t *testing.T) {
+	s := startServer(t)
+	n := New(config.Notifications{Events: []string{EventPick}, Timeout: config.Duration(2 * time.Second)}, nil)
+	defer n.Close()
+
+	n.Send(EventDelete, "not enabled", "")
+	n.Send(EventPick, "Copied", "some text")
+	c := s.next(t)
+	if c.App != appName || c.Summary != "Copied" || c.Body != "some text" || len(c.Actions) != 0 || c.Timeout != 2000 {
+		t.Errorf("Notify call = %+v", c)
+	}
+	select {
+	case c := <-s.calls:
+		t.Errorf("disabled event was sent: %+v", c)
+	default:
+	}
+}
+
+func TestSendWithActionsUndo(t *testing.T) {
+	s := startServer(t)
+	n := New(config.Notifications{Events: []string{EventDelete}}, nil)
+	defer n.Close()
+
+	clicked := make(chan string, 1)
+	n.SendWithActions(EventDelete, "Entry deleted", "text", []Action{{Key: "undo", Label: "Undo"}}, func(key string) {
+		clicked <- key
+	})
+	c := s.next(t)
+	if len(c.Actions) != 2 || c.Actions[0] != "undo" || c.Actions[1] != "Undo" {
+		t.Fatalf("actions = %v, want [undo Undo]", c.Actions)
+	}
+	if err := s.conn.Emit(busPath, busName+".ActionInvoked", uint32(7), "undo"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case key := <-clicked:
+		if key != "undo" {
+			t.Errorf("callback key = %q, want undo", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("undo callback not called")
+	}
+	n.Wait()
+}
+
+func TestSendWithActionsClosed(t *testing.T) {
+	s := startServer(t)
+	n := New(config.Notifications{Events: []string{EventDelete}}, nil)
+	defer n.Close()
+
+	called := false
+	n.SendWithActions(EventDelete, "Entry deleted", "", []Action{{Key: "undo", Label: "Undo"}}, func(string) {
+		called = true
+	})
+	s.next(t)
+	if err := s.conn.Emit(busPath, busName+".NotificationClosed", uint32(7), uint32(2)); err != nil {
+		t.Fatal(err)
+	}
+	n.Wait()
+	if called {
+		t.Error("callback called for a closed notification")
+	}
+}
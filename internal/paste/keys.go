@@ -0,0 +1,98 @@
+package paste
+
+import (
+	"fmt"
+	"strings"
+)
+
+// key describes one key in the encodings used by the paste methods.
+type key struct {
+	// keysym is the X11 keysym.
+	keysym uint32
+	// name is the xkb keysym name understood by wtype.
+	name string
+	// code is the Linux input event code used by ydotool.
+	code int
+}
+
+var modifiers = map[string]key{
+	"ctrl":  {keysym: 0xffe3, name: "ctrl", code: 29},
+	"shift": {keysym: 0xffe1, name: "shift", code: 42},
+	"alt":   {keysym: 0xffe9, name: "alt", code: 56},
+	"super": {keysym: 0xffeb, name: "logo", code: 125},
+}
+
+var modifierAliases = map[string]string{
+	"control": "ctrl",
+	"logo":    "super",
+	"win":     "super",
+	"meta":    "alt",
+}
+
+var namedKeys = map[string]key{
+	"insert": {keysym: 0xff63, name: "Insert", code: 110},
+	"enter":  {keysym: 0xff0d, name: "Return", code: 28},
+	"return": {keysym: 0xff0d, name: "Return", code: 28},
+	"tab":    {keysym: 0xff09, name: "Tab", code: 15},
+	"space":  {keysym: 0x20, name: "space", code: 57},
+}
+
+// keyRows maps letters and digits to Linux event codes, which follow the
+// physical US layout rows.
+var keyRows = []struct {
+	chars string
+	first int
+}{
+	{"1234567890", 2},
+	{"qwertyuiop", 16},
+	{"asdfghjkl", 30},
+	{"zxcvbnm", 44},
+}
+
+// Keystroke is a key with modifiers, such as ctrl+shift+v.
+type Keystroke struct {
+	Mods []key
+	Key  key
+}
+
+// ParseKeystroke parses "mod+mod+key". Modifiers are ctrl, shift, alt and
+// super; keys are letters, digits, insert, enter, tab and space.
+func ParseKeystroke(s string) (Keystroke, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "+")
+	var ks Keystroke
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if i < len(parts)-1 {
+			if alias, ok := modifierAliases[part]; ok {
+				part = alias
+			}
+			mod, ok := modifiers[part]
+			if !ok {
+				return Keystroke{}, fmt.Errorf("unknown modifier %q in keystroke %q", part, s)
+			}
+			ks.Mods = append(ks.Mods, mod)
+			continue
+		}
+		k, ok := lookupKey(part)
+		if !ok {
+			return Keystroke{}, fmt.Errorf("unknown key %q in keystroke %q", part, s)
+		}
+		ks.Key = k
+	}
+	return ks, nil
+}
+
+func lookupKey(name string) (key, bool) {
+	if k, ok := namedKeys[name]; ok {
+		return k, true
+	}
+	if len(name) != 1 {
+		return key{}, false
+	}
+	for _, row := range keyRows {
+		if i := strings.IndexByte(row.chars, name[0]); i >= 0 {
+			return key{keysym: uint32(name[0]), name: name, code: row.first + i}, true
+		}
+	}
+	return key{}, false
+}
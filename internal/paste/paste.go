@@ -0,0 +1,111 @@
+// Package paste restores focus to the window used before the popup opened
+// and synthesizes the paste keystroke in it.
+package paste
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"stashclip/internal/clipboard"
+	"stashclip/internal/config"
+)
+
+const (
+	defaultKeystroke         = "ctrl+v"
+	defaultTerminalKeystroke = "ctrl+shift+v"
+	defaultDelay             = 150 * time.Millisecond
+)
+
+// terminalClasses are window classes (X11) or app ids (Wayland) that paste
+// with the terminal keystroke.
+var terminalClasses = []string{
+	"alacritty", "foot", "footclient", "gnome-terminal-server", "kitty",
+	"konsole", "org.gnome.console", "org.gnome.ptyxis", "org.wezfurlong.wezterm",
+	"st-256color", "terminator", "tilix", "urxvt", "wezterm", "x-terminal-emulator",
+	"xfce4-terminal", "xterm", "com.mitchellh.ghostty", "lxterminal", "mate-terminal",
+}
+
+// Target is the window focused before the popup opened.
+type Target struct {
+	// Class is the window class (X11) or app id (Wayland), lowercased.
+	Class string
+	// window is the X11 window to activate again, 0 when unknown.
+	window uint32
+	// focus is a compositor command that focuses the window on Wayland.
+	focus []string
+}
+
+// Paster sends the paste keystroke configured for the target window.
+type Paster struct {
+	keystroke         Keystroke
+	terminalKeystroke Keystroke
+	terminals         map[string]bool
+	delay             time.Duration
+}
+
+// New validates cfg and returns a Paster.
+func New(cfg config.Paste) (*Paster, error) {
+	p := &Paster{terminals: map[string]bool{}, delay: time.Duration(cfg.Delay)}
+	if p.delay <= 0 {
+		p.delay = defaultDelay
+	}
+	var err error
+	if p.keystroke, err = ParseKeystroke(orDefault(cfg.Keystroke, defaultKeystroke)); err != nil {
+		return nil, fmt.Errorf("paste error: %w", err)
+	}
+	if p.terminalKeystroke, err = ParseKeystroke(orDefault(cfg.TerminalKeystroke, defaultTerminalKeystroke)); err != nil {
+		return nil, fmt.Errorf("paste error: %w", err)
+	}
+	for _, class := range append(terminalClasses, cfg.TerminalClasses...) {
+		p.terminals[strings.ToLower(class)] = true
+	}
+	return p, nil
+}
+
+// Capture records the focused window. It is best effort: an unknown window
+// yields an empty Target, which still pastes with the default keystroke.
+func (p *Paster) Capture() Target {
+	var (
+		t   Target
+		err error
+	)
+	switch clipboard.SessionType() {
+	case "x11":
+		t, err = captureX11()
+	case "wayland":
+		t, err = captureWayland()
+	}
+	if err != nil {
+		return Target{}
+	}
+	return t
+}
+
+// Paste focuses the target window and sends the paste keystroke.
+func (p *Paster) Paste(t Target) error {
+	ks := p.keystroke
+	if p.terminals[t.Class] {
+		ks = p.terminalKeystroke
+	}
+	var err error
+	switch clipboard.SessionType() {
+	case "x11":
+		err = pasteX11(t, ks, p.delay)
+	case "wayland":
+		err = pasteWayland(t, ks, p.delay)
+	default:
+		err = fmt.Errorf("no graphical session")
+	}
+	if err != nil {
+		return fmt.Errorf("paste error: %w", err)
+	}
+	return nil
+}
+
+func orDefault(s, def string) string {
+	if strings.TrimSpace(s) == "" {
+		return def
+	}
+	return s
+}
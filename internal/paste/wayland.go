@@ -0,0 +1,146 @@
+package paste
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// captureWayland asks the compositor for the focused window. Only Hyprland
+// and sway expose it; elsewhere the compositor returns focus on its own
+// when the popup closes.
+func captureWayland() (Target, error) {
+	switch {
+	case os.Getenv("HYPRLAND_INSTANCE_SIGNATURE") != "":
+		return captureHyprland()
+	case os.Getenv("SWAYSOCK") != "":
+		return captureSway()
+	}
+	return Target{}, nil
+}
+
+func captureHyprland() (Target, error) {
+	out, err := exec.Command("hyprctl", "activewindow", "-j").Output()
+	if err != nil {
+		return Target{}, err
+	}
+	var win struct {
+		Address string `json:"address"`
+		Class   string `json:"class"`
+	}
+	if err := json.Unmarshal(out, &win); err != nil {
+		return Target{}, err
+	}
+	t := Target{Class: strings.ToLower(win.Class)}
+	if win.Address != "" {
+		t.focus = []string{"hyprctl", "dispatch", "focuswindow", "address:" + win.Address}
+	}
+	return t, nil
+}
+
+// swayNode is the subset of the sway tree needed to find the focused window.
+type swayNode struct {
+	ID               int64  `json:"id"`
+	Focused          bool   `json:"focused"`
+	AppID            string `json:"app_id"`
+	WindowProperties struct {
+		Class string `json:"class"`
+	} `json:"window_properties"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+func captureSway() (Target, error) {
+	out, err := exec.Command("swaymsg", "-t", "get_tree").Output()
+	if err != nil {
+		return Target{}, err
+	}
+	var tree swayNode
+	if err := json.Unmarshal(out, &tree); err != nil {
+		return Target{}, err
+	}
+	node := findFocused(&tree)
+	if node == nil {
+		return Target{}, nil
+	}
+	class := node.AppID
+	if class == "" {
+		class = node.WindowProperties.Class
+	}
+	return Target{
+		Class: strings.ToLower(class),
+		focus: []string{"swaymsg", "[con_id=" + strconv.FormatInt(node.ID, 10) + "]", "focus"},
+	}, nil
+}
+
+func findFocused(n *swayNode) *swayNode {
+	if n.Focused {
+		return n
+	}
+	for _, children := range [][]swayNode{n.Nodes, n.FloatingNodes} {
+		for i := range children {
+			if f := findFocused(&children[i]); f != nil {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// pasteWayland types the keystroke with wtype (virtual-keyboard protocol,
+// wlroots compositors) or ydotool (uinput, any compositor).
+func pasteWayland(t Target, ks Keystroke, delay time.Duration) error {
+	if len(t.focus) > 0 {
+		_ = exec.Command(t.focus[0], t.focus[1:]...).Run()
+	}
+	time.Sleep(delay)
+
+	switch {
+	case hasCommand("wtype"):
+		return runTool("wtype", wtypeArgs(ks))
+	case hasCommand("ydotool"):
+		return runTool("ydotool", ydotoolArgs(ks))
+	}
+	return fmt.Errorf("auto-paste on Wayland needs wtype or ydotool")
+}
+
+func wtypeArgs(ks Keystroke) []string {
+	var args []string
+	for _, m := range ks.Mods {
+		args = append(args, "-M", m.name)
+	}
+	args = append(args, "-k", ks.Key.name)
+	for i := len(ks.Mods) - 1; i >= 0; i-- {
+		args = append(args, "-m", ks.Mods[i].name)
+	}
+	return args
+}
+
+func ydotoolArgs(ks Keystroke) []string {
+	keys := append(append([]key(nil), ks.Mods...), ks.Key)
+	args := []string{"key"}
+	for _, k := range keys {
+		args = append(args, strconv.Itoa(k.code)+":1")
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		args = append(args, strconv.Itoa(keys[i].code)+":0")
+	}
+	return args
+}
+
+func runTool(name string, args []string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
@@ -0,0 +1,129 @@
+package paste
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgb/xtest"
+
+	"stashclip/internal/clipboard"
+)
+
+func captureX11() (Target, error) {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return Target{}, err
+	}
+	defer conn.Close()
+
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	win, err := activeWindow(conn, root)
+	if err != nil || win == 0 {
+		focus, ferr := xproto.GetInputFocus(conn).Reply()
+		if ferr != nil {
+			return Target{}, ferr
+		}
+		win = focus.Focus
+	}
+	return Target{Class: strings.ToLower(clipboard.WindowApp(conn, win)), window: uint32(win)}, nil
+}
+
+// activeWindow reads _NET_ACTIVE_WINDOW from the root window.
+func activeWindow(conn *xgb.Conn, root xproto.Window) (xproto.Window, error) {
+	atom, err := internAtom(conn, "_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return 0, err
+	}
+	prop, err := xproto.GetProperty(conn, false, root, atom, xproto.AtomWindow, 0, 1).Reply()
+	if err != nil {
+		return 0, err
+	}
+	if prop.Format != 32 || len(prop.Value) < 4 {
+		return 0, nil
+	}
+	return xproto.Window(xgb.Get32(prop.Value)), nil
+}
+
+func pasteX11(t Target, ks Keystroke, delay time.Duration) error {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := xtest.Init(conn); err != nil {
+		return fmt.Errorf("xtest unavailable: %w", err)
+	}
+
+	setup := xproto.Setup(conn)
+	root := setup.DefaultScreen(conn).Root
+	if t.window != 0 {
+		activate(conn, root, xproto.Window(t.window))
+	}
+	time.Sleep(delay)
+
+	keys := append(append([]key(nil), ks.Mods...), ks.Key)
+	codes := make([]xproto.Keycode, len(keys))
+	for i, k := range keys {
+		code, err := keycode(conn, setup, k.keysym)
+		if err != nil {
+			return err
+		}
+		codes[i] = code
+	}
+	for _, code := range codes {
+		if err := fakeKey(conn, root, xproto.KeyPress, code); err != nil {
+			return err
+		}
+	}
+	for i := len(codes) - 1; i >= 0; i-- {
+		if err := fakeKey(conn, root, xproto.KeyRelease, codes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activate asks the window manager to focus win, falling back to setting
+// the input focus directly.
+func activate(conn *xgb.Conn, root, win xproto.Window) {
+	if atom, err := internAtom(conn, "_NET_ACTIVE_WINDOW"); err == nil {
+		// Source indication 2: the request comes from a pager-like tool,
+		// which window managers honor without focus-stealing checks.
+		data := xproto.ClientMessageDataUnionData32New([]uint32{2, uint32(xproto.TimeCurrentTime), 0, 0, 0})
+		ev := xproto.ClientMessageEvent{Format: 32, Window: win, Type: atom, Data: data}
+		mask := uint32(xproto.EventMaskSubstructureRedirect | xproto.EventMaskSubstructureNotify)
+		xproto.SendEvent(conn, false, root, mask, string(ev.Bytes()))
+	}
+	xproto.SetInputFocus(conn, xproto.InputFocusParent, win, xproto.TimeCurrentTime)
+}
+
+// keycode finds a keycode producing keysym in the current keyboard mapping.
+func keycode(conn *xgb.Conn, setup *xproto.SetupInfo, keysym uint32) (xproto.Keycode, error) {
+	count := byte(setup.MaxKeycode - setup.MinKeycode + 1)
+	mapping, err := xproto.GetKeyboardMapping(conn, setup.MinKeycode, count).Reply()
+	if err != nil {
+		return 0, err
+	}
+	per := int(mapping.KeysymsPerKeycode)
+	for i, sym := range mapping.Keysyms {
+		if uint32(sym) == keysym {
+			return setup.MinKeycode + xproto.Keycode(i/per), nil
+		}
+	}
+	return 0, fmt.Errorf("no keycode for keysym 0x%x", keysym)
+}
+
+func fakeKey(conn *xgb.Conn, root xproto.Window, typ byte, code xproto.Keycode) error {
+	return xtest.FakeInputChecked(conn, typ, byte(code), 0, root, 0, 0, 0).Check()
+}
+
+func internAtom(conn *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, err
+	}
+	return reply.Atom, nil
+}
@@ -0,0 +1,144 @@
+package popup
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// Backend is a picker able to show the history list and small menus.
+type Backend interface {
+	Name() string
+	// Available reports whether the backend can run in this session.
+	Available() bool
+	Select(items []Item) (Selection, error)
+	// Choose returns the key of the chosen option.
+	Choose(prompt string, options []Option) (string, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	builtin    = map[string]Backend{}
+	preferred  []Backend
+	fallbacks  []Backend
+
+	defaultProvider string
+)
+
+// SetDefaultProvider names the backend used when STASHCLIP_POPUP_PROVIDER is unset.
+func SetDefaultProvider(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	defaultProvider = strings.ToLower(strings.TrimSpace(name))
+}
+
+// Register adds a backend that is preferred over the built-in ones when
+// available. Registering a name again replaces the previous backend.
+func Register(b Backend) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	preferred = replaceBackend(preferred, b)
+}
+
+// RegisterFallback adds a backend tried only after the built-in ones.
+func RegisterFallback(b Backend) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	fallbacks = replaceBackend(fallbacks, b)
+}
+
+func registerBuiltin(b Backend) {
+	builtin[b.Name()] = b
+}
+
+func replaceBackend(list []Backend, b Backend) []Backend {
+	for i, existing := range list {
+		if existing.Name() == b.Name() {
+			list[i] = b
+			return list
+		}
+	}
+	return append(list, b)
+}
+
+// Lookup returns the backend registered as name.
+func Lookup(name string) (Backend, bool) {
+	for _, b := range Backends() {
+		if b.Name() == name {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+// Backends returns every backend in preference order.
+func Backends() []Backend {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	out := make([]Backend, 0, len(preferred)+len(builtin)+len(fallbacks))
+	out = append(out, preferred...)
+	for _, name := range builtinOrder() {
+		if b, ok := builtin[name]; ok {
+			out = append(out, b)
+		}
+	}
+	return append(out, fallbacks...)
+}
+
+// Preferred returns the backend named by STASHCLIP_POPUP_PROVIDER or the
+// configured default, or the first available one.
+func Preferred() (Backend, error) {
+	forced := strings.ToLower(strings.TrimSpace(os.Getenv("STASHCLIP_POPUP_PROVIDER")))
+	if forced == "" {
+		registryMu.RLock()
+		forced = defaultProvider
+		registryMu.RUnlock()
+	}
+	if forced != "" {
+		b, ok := Lookup(forced)
+		if !ok || !b.Available() {
+			return nil, errorf("err.popup.unavailable", forced)
+		}
+		return b, nil
+	}
+	for _, b := range Backends() {
+		if b.Available() {
+			return b, nil
+		}
+	}
+	return nil, errorf("err.popup.none", strings.Join(builtinOrder(), ", "))
+}
+
+var dialogProviders = []string{"yad", "zenity", "kdialog"}
+
+// builtinOrder prefers dmenu-style pickers on tiling window managers and
+// dialogs on full desktop environments.
+func builtinOrder() []string {
+	menus := dmenuOrder(os.Getenv("WAYLAND_DISPLAY") != "")
+	order := make([]string, 0, len(dialogProviders)+len(menus))
+	if tilingSession() {
+		order = append(order, menus...)
+		return append(order, dialogProviders...)
+	}
+	order = append(order, dialogProviders...)
+	return append(order, menus...)
+}
+
+func tilingSession() bool {
+	for _, env := range []string{"SWAYSOCK", "HYPRLAND_INSTANCE_SIGNATURE", "I3SOCK", "NIRI_SOCKET"} {
+		if os.Getenv(env) != "" {
+			return true
+		}
+	}
+	desktop := strings.ToLower(os.Getenv("XDG_CURRENT_DESKTOP"))
+	for _, wm := range []string{"sway", "hyprland", "i3", "river", "niri", "wayfire", "bspwm", "dwm", "qtile", "awesome", "xmonad"} {
+		if strings.Contains(desktop, wm) {
+			return true
+		}
+	}
+	return false
+}
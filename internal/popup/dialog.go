@@ -0,0 +1,376 @@
+package popup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"stashclip/internal/i18n"
+)
+
+func init() {
+	registerBuiltin(yadBackend{})
+	registerBuiltin(zenityBackend{})
+	registerBuiltin(kdialogBackend{})
+}
+
+// Exit codes of the extra yad buttons. yad only prints the selection for
+// buttons with even exit codes.
+const (
+	yadTransformExit = 2
+	yadMenuExit      = 4
+	yadDeleteExit    = 6
+	yadPinExit       = 8
+	yadEditExit      = 10
+	yadPlainExit     = 12
+	yadSnippetsExit  = 14
+)
+
+var yadActions = map[int]Action{
+	0:                ActionCopy,
+	yadTransformExit: ActionTransform,
+	yadMenuExit:      ActionMenu,
+	yadDeleteExit:    ActionDelete,
+	yadPinExit:       ActionPin,
+	yadEditExit:      ActionEdit,
+	yadPlainExit:     ActionCopyPlain,
+	yadSnippetsExit:  ActionSnippets,
+}
+
+type yadBackend struct{}
+
+func (yadBackend) Name() string    { return "yad" }
+func (yadBackend) Available() bool { return hasCommand("yad") }
+
+func (yadBackend) Select(items []Item) (Selection, error) {
+	now := time.Now()
+	out, code, err := yadSelectPaned(items, now)
+	if errors.Is(err, errNoPreview) {
+		args := append([]string{"--list", "--text=" + i18n.T("popup.select")}, yadWindowArgs()...)
+		out, code, err = runPicker("yad", append(args, yadListArgs(items, now)...), "")
+	}
+	if err != nil {
+		return Selection{}, err
+	}
+	action, ok := yadActions[code]
+	if !ok {
+		return Selection{}, ErrCanceled
+	}
+	if action == ActionSnippets {
+		return Selection{Action: action}, nil
+	}
+	ids, err := parseSelectedIDs(out)
+	if err != nil {
+		return Selection{}, err
+	}
+	return multiSelection(ids, action), nil
+}
+
+func yadWindowArgs() []string {
+	return []string{
+		"--title=Stashclip",
+		"--width=1100",
+		"--height=600",
+		yadButton("popup.button.copy", 0),
+		yadButton("popup.button.plain", yadPlainExit),
+		yadButton("popup.button.edit", yadEditExit),
+		yadButton("popup.button.pin", yadPinExit),
+		yadButton("popup.button.delete", yadDeleteExit),
+		yadButton("popup.button.menu", yadMenuExit),
+		yadButton("popup.button.trans", yadTransformExit),
+		yadButton("popup.button.snippets", yadSnippetsExit),
+		yadButton("popup.button.close", 1),
+	}
+}
+
+func yadButton(key string, code int) string {
+	return "--button=" + i18n.T(key) + ":" + strconv.Itoa(code)
+}
+
+// yadListArgs are the list columns and rows: a one-line summary of each
+// item with its age, kind, line count and size. yad cannot preselect a row,
+// so the focused item comes first.
+func yadListArgs(items []Item, now time.Time) []string {
+	if i := focusIndex(items); i > 0 {
+		items = append(append([]Item{items[i]}, items[:i]...), items[i+1:]...)
+	}
+	args := []string{
+		"--multiple",
+		"--column=ID:NUM",
+		"--column=" + i18n.T("popup.column.age") + ":TEXT",
+		"--column=" + i18n.T("popup.column.kind") + ":TEXT",
+		"--column=" + i18n.T("popup.column.lines") + ":NUM",
+		"--column=" + i18n.T("popup.column.size") + ":TEXT",
+		"--column=" + i18n.T("popup.column.text") + ":TEXT",
+		"--print-column=1",
+		"--separator=\n",
+	}
+	for _, item := range items {
+		args = append(args,
+			strconv.Itoa(item.ID),
+			Age(item.AddedAt, now),
+			pinnedKind(item),
+			strconv.Itoa(LineCount(item.Text)),
+			Size(len(item.Text)),
+			Summary(item.Text, summaryWidth),
+		)
+	}
+	return args
+}
+
+// errNoPreview reports that the preview pane could not be set up.
+var errNoPreview = errors.New("popup preview unavailable")
+
+// yadSelectPaned shows the list next to a text pane with the full selected
+// item. Each preview is written to a temp file; the list select action
+// copies it into a FIFO that the text pane reads in --listen mode, where a
+// form feed clears the previous text.
+func yadSelectPaned(items []Item, now time.Time) ([]byte, int, error) {
+	dir, err := os.MkdirTemp("", "stashclip-preview-")
+	if err != nil {
+		return nil, 0, errNoPreview
+	}
+	defer os.RemoveAll(dir)
+	for _, item := range items {
+		if err := os.WriteFile(filepath.Join(dir, strconv.Itoa(item.ID)), []byte(Preview(item, now)), 0o600); err != nil {
+			return nil, 0, errNoPreview
+		}
+	}
+	pipe := filepath.Join(dir, "pipe")
+	if err := syscall.Mkfifo(pipe, 0o600); err != nil {
+		return nil, 0, errNoPreview
+	}
+	// Opened read-write so the pane never sees EOF between previews.
+	fifo, err := os.OpenFile(pipe, os.O_RDWR, 0)
+	if err != nil {
+		return nil, 0, errNoPreview
+	}
+	defer fifo.Close()
+	first := items[0]
+	if i := focusIndex(items); i >= 0 {
+		first = items[i]
+	}
+	go fmt.Fprint(fifo, Preview(first, now))
+
+	key := strconv.Itoa(os.Getpid())
+	pane := exec.Command("yad", "--plug="+key, "--tabnum=2", "--text-info", "--listen", "--wrap")
+	pane.Stdin = fifo
+	if err := pane.Start(); err != nil {
+		return nil, 0, fail(err)
+	}
+	defer func() {
+		_ = pane.Process.Kill()
+		_ = pane.Wait()
+	}()
+
+	action := fmt.Sprintf(`sh -c '{ printf "\f"; cat "$0/$1"; } > "$0/pipe"' '%s'`, dir)
+	listArgs := []string{"--plug=" + key, "--tabnum=1", "--list", "--select-action=" + action}
+	var out bytes.Buffer
+	list := exec.Command("yad", append(listArgs, yadListArgs(items, now)...)...)
+	list.Stdout = &out
+	if err := list.Start(); err != nil {
+		return nil, 0, fail(err)
+	}
+	listDone := make(chan struct{})
+	go func() {
+		_ = list.Wait()
+		close(listDone)
+	}()
+
+	mainArgs := append([]string{"--paned", "--key=" + key, "--orient=hor", "--splitter=640", "--text=" + i18n.T("popup.select")}, yadWindowArgs()...)
+	_, code, err := runPicker("yad", mainArgs, "")
+	select {
+	case <-listDone:
+	case <-time.After(2 * time.Second):
+		_ = list.Process.Kill()
+		<-listDone
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return out.Bytes(), code, nil
+}
+
+func (yadBackend) Choose(prompt string, options []Option) (string, error) {
+	args := []string{
+		"--list",
+		"--title=Stashclip",
+		"--text=" + prompt,
+		"--width=480",
+		"--height=420",
+		yadButton("popup.button.ok", 0),
+		yadButton("popup.button.back", 1),
+		"--column=Key:HD",
+		"--column=" + i18n.T("popup.column.action") + ":TEXT",
+		"--print-column=1",
+		"--separator=\n",
+	}
+	for _, o := range options {
+		args = append(args, o.Key, o.Label)
+	}
+	return chooseOutput(runPicker("yad", args, ""))
+}
+
+func (yadBackend) Ask(prompt string) (string, error) {
+	args := []string{"--entry", "--title=Stashclip", "--text=" + prompt, "--width=480"}
+	return chooseOutput(runPicker("yad", args, ""))
+}
+
+type zenityBackend struct{}
+
+func (zenityBackend) Name() string    { return "zenity" }
+func (zenityBackend) Available() bool { return hasCommand("zenity") }
+
+func (zenityBackend) Select(items []Item) (Selection, error) {
+	args := []string{
+		"--list",
+		"--title=Stashclip",
+		"--text=" + i18n.T("popup.select"),
+		"--width=980",
+		"--height=600",
+		"--ok-label=" + i18n.T("popup.button.copy"),
+		"--cancel-label=" + i18n.T("popup.button.close"),
+		"--multiple",
+		"--column=ID",
+		"--column=" + i18n.T("popup.column.age"),
+		"--column=" + i18n.T("popup.column.kind"),
+		"--column=" + i18n.T("popup.column.lines"),
+		"--column=" + i18n.T("popup.column.size"),
+		"--column=" + i18n.T("popup.column.text"),
+		"--hide-column=1",
+		"--print-column=1",
+	}
+	now := time.Now()
+	for _, item := range items {
+		args = append(args,
+			strconv.Itoa(item.ID),
+			Age(item.AddedAt, now),
+			pinnedKind(item),
+			strconv.Itoa(LineCount(item.Text)),
+			Size(len(item.Text)),
+			Summary(item.Text, summaryWidth),
+		)
+	}
+	out, code, err := runPicker("zenity", args, "")
+	if err != nil {
+		return Selection{}, err
+	}
+	if code != 0 {
+		return Selection{}, ErrCanceled
+	}
+	ids, err := parseSelectedIDs(out)
+	if err != nil {
+		return Selection{}, err
+	}
+	return multiSelection(ids, ActionCopy), nil
+}
+
+func (zenityBackend) Choose(prompt string, options []Option) (string, error) {
+	args := []string{
+		"--list",
+		"--title=Stashclip",
+		"--text=" + prompt,
+		"--width=480",
+		"--height=420",
+		"--column=Key",
+		"--column=" + i18n.T("popup.column.action"),
+		"--hide-column=1",
+		"--print-column=1",
+	}
+	for _, o := range options {
+		args = append(args, o.Key, o.Label)
+	}
+	return chooseOutput(runPicker("zenity", args, ""))
+}
+
+func (zenityBackend) Ask(prompt string) (string, error) {
+	args := []string{"--entry", "--title=Stashclip", "--text=" + prompt}
+	return chooseOutput(runPicker("zenity", args, ""))
+}
+
+type kdialogBackend struct{}
+
+func (kdialogBackend) Name() string    { return "kdialog" }
+func (kdialogBackend) Available() bool { return hasCommand("kdialog") }
+
+// Select shows the item menu. kdialog menus have no extra buttons, so the
+// choice is followed by the action dialog (ActionMenu).
+func (kdialogBackend) Select(items []Item) (Selection, error) {
+	args := []string{"--title", "Stashclip"}
+	if i := focusIndex(items); i >= 0 {
+		args = append(args, "--default", strconv.Itoa(items[i].ID))
+	}
+	args = append(args, "--menu", i18n.T("popup.select.short"))
+	for _, item := range items {
+		args = append(args, strconv.Itoa(item.ID), kdialogItemLabel(item))
+	}
+	sel, err := copySelection(runPicker("kdialog", args, ""))
+	if err != nil {
+		return Selection{}, err
+	}
+	sel.Action = ActionMenu
+	return sel, nil
+}
+
+func kdialogItemLabel(item Item) string {
+	return fmt.Sprintf("[%s]  %s  (%s)", pinnedKind(item), Summary(item.Text, summaryWidth), Details(item, time.Now()))
+}
+
+// pinnedKind is the kind badge with a pin marker for pinned items.
+func pinnedKind(item Item) string {
+	if item.Pinned {
+		return "* " + item.Kind
+	}
+	return item.Kind
+}
+
+func (kdialogBackend) Choose(prompt string, options []Option) (string, error) {
+	args := []string{"--title", "Stashclip", "--menu", prompt}
+	for _, o := range options {
+		args = append(args, o.Key, o.Label)
+	}
+	return chooseOutput(runPicker("kdialog", args, ""))
+}
+
+func (kdialogBackend) Ask(prompt string) (string, error) {
+	return chooseOutput(runPicker("kdialog", []string{"--title", "Stashclip", "--inputbox", prompt}, ""))
+}
+
+// copySelection interprets the result of a dialog without extra buttons.
+func copySelection(out []byte, code int, err error) (Selection, error) {
+	if err != nil {
+		return Selection{}, err
+	}
+	if code != 0 {
+		return Selection{}, ErrCanceled
+	}
+	id, err := parseSelectedID(out)
+	if err != nil {
+		return Selection{}, err
+	}
+	return Selection{ID: id, Action: ActionCopy}, nil
+}
+
+// chooseOutput interprets the result of a dialog printing the chosen key.
+func chooseOutput(out []byte, code int, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return "", ErrCanceled
+	}
+	return string(trimNewline(out)), nil
+}
+
+func trimNewline(b []byte) []byte {
+	for len(b) > 0 && (b[len(b)-1] == '\n' || b[len(b)-1] == '\r') {
+		b = b[:len(b)-1]
+	}
+	return b
+}
@@ -0,0 +1,218 @@
+package popup
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"stashclip/internal/i18n"
+)
+
+// dmenuProviders are the dmenu-protocol pickers in order of preference on
+// Wayland.
+var dmenuProviders = []string{"fuzzel", "wofi", "rofi", "bemenu", "dmenu"}
+
+// waylandOnly marks the pickers that do not run on X11.
+var waylandOnly = map[string]bool{"fuzzel": true, "wofi": true}
+
+func init() {
+	for _, name := range dmenuProviders {
+		registerBuiltin(dmenuBackend{name: name})
+	}
+}
+
+// dmenuOrder returns dmenuProviders for the session: outside Wayland the
+// Wayland-only pickers come last.
+func dmenuOrder(wayland bool) []string {
+	if wayland {
+		return dmenuProviders
+	}
+	order := make([]string, 0, len(dmenuProviders))
+	for _, name := range dmenuProviders {
+		if !waylandOnly[name] {
+			order = append(order, name)
+		}
+	}
+	for _, name := range dmenuProviders {
+		if waylandOnly[name] {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// Actions of the dmenu-protocol custom keybindings (rofi -kb-custom-N,
+// fuzzel custom-N). The first custom binding exits with 10.
+var dmenuActions = map[int]Action{
+	0:  ActionCopy,
+	10: ActionDelete,
+	11: ActionPin,
+	12: ActionEdit,
+	13: ActionCopyPlain,
+	14: ActionTransform,
+	15: ActionMenu,
+	16: ActionSnippets,
+}
+
+// rofiSep separates multi-line rofi rows; it cannot appear in item text.
+const rofiSep = "\x1f"
+
+// rofiRowLines is the height of a rofi row: the summary and a preview of
+// the following lines.
+const rofiRowLines = 3
+
+// rofiKeys are the rofi bindings for custom-1 to custom-7.
+var rofiKeys = []string{"Alt+d", "Alt+p", "Alt+e", "Alt+c", "Alt+t", "Alt+a", "Alt+s"}
+
+// dmenuBackend drives pickers that read lines on stdin and print the chosen line.
+type dmenuBackend struct {
+	name string
+}
+
+func (b dmenuBackend) Name() string    { return b.name }
+func (b dmenuBackend) Available() bool { return hasCommand(b.name) }
+
+func (b dmenuBackend) args(prompt string, withActions bool, focus int) []string {
+	switch b.name {
+	case "rofi":
+		args := []string{"-dmenu", "-i", "-p", "Stashclip", "-mesg", prompt}
+		if withActions {
+			// Items span rofiRowLines lines; rofi prints row indexes.
+			args = append(args, "-multi-select", "-sep", rofiSep, "-eh", strconv.Itoa(rofiRowLines), "-format", "i")
+			for i, k := range rofiKeys {
+				args = append(args, "-kb-custom-"+strconv.Itoa(i+1), k)
+			}
+		}
+		if focus >= 0 {
+			args = append(args, "-selected-row", strconv.Itoa(focus))
+		}
+		return args
+	case "wofi":
+		return []string{"--dmenu", "--insensitive", "--prompt", prompt}
+	case "fuzzel":
+		return []string{"--dmenu", "--prompt", prompt + ": "}
+	default:
+		return []string{"-i", "-l", "20", "-p", prompt}
+	}
+}
+
+func (b dmenuBackend) Select(items []Item) (Selection, error) {
+	now := time.Now()
+	prompt := i18n.T("popup.select")
+	sep := "\n"
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		if b.name == "rofi" {
+			lines = append(lines, rofiRow(item, now))
+		} else {
+			lines = append(lines, dmenuLine(item, now))
+		}
+	}
+	if b.name == "rofi" {
+		prompt += "  " + i18n.T("popup.rofi.keys")
+		sep = rofiSep
+	}
+	out, code, err := runPicker(b.name, b.args(prompt, true, focusIndex(items)), strings.Join(lines, sep)+sep)
+	if err != nil {
+		return Selection{}, err
+	}
+	action, ok := dmenuActions[code]
+	if !ok {
+		return Selection{}, ErrCanceled
+	}
+	if action == ActionSnippets {
+		return Selection{Action: action}, nil
+	}
+	var ids []int
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if b.name == "rofi" {
+			i, err := strconv.Atoi(string(bytes.TrimSpace(line)))
+			if err != nil || i < 0 || i >= len(items) {
+				return Selection{}, errorf("err.popup.selection")
+			}
+			ids = append(ids, items[i].ID)
+			continue
+		}
+		id, err := parseLeadingID(line)
+		if err != nil {
+			return Selection{}, err
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return Selection{}, ErrCanceled
+	}
+	return multiSelection(ids, action), nil
+}
+
+func (b dmenuBackend) Choose(prompt string, options []Option) (string, error) {
+	lines := make([]string, 0, len(options))
+	for _, o := range options {
+		lines = append(lines, o.Label)
+	}
+	out, code, err := runPicker(b.name, b.args(prompt, false, -1), strings.Join(lines, "\n")+"\n")
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return "", ErrCanceled
+	}
+	label := string(trimNewline(out))
+	for _, o := range options {
+		if o.Label == label {
+			return o.Key, nil
+		}
+	}
+	return "", errorf("err.popup.selection")
+}
+
+// Ask shows an empty menu; the typed text is printed as the choice.
+func (b dmenuBackend) Ask(prompt string) (string, error) {
+	out, code, err := runPicker(b.name, b.args(prompt, false, -1), "")
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return "", ErrCanceled
+	}
+	return string(trimNewline(out)), nil
+}
+
+// dmenuLine renders an item as one line prefixed by its stable ID.
+func dmenuLine(item Item, now time.Time) string {
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(item.ID))
+	b.WriteString("  ")
+	if item.Pinned {
+		b.WriteString("* ")
+	}
+	if item.Kind != "" {
+		b.WriteString("[" + item.Kind + "] ")
+	}
+	b.WriteString(Summary(item.Text, summaryWidth))
+	b.WriteString("  (" + Details(item, now) + ")")
+	return b.String()
+}
+
+// rofiRow renders the item line followed by a preview of the next lines.
+func rofiRow(item Item, now time.Time) string {
+	row := []string{dmenuLine(item, now)}
+	lines := strings.Split(strings.TrimSpace(item.Text), "\n")
+	for i := 1; i < len(lines) && len(row) < rofiRowLines; i++ {
+		row = append(row, "      "+Summary(lines[i], summaryWidth))
+	}
+	return strings.ReplaceAll(strings.Join(row, "\n"), rofiSep, " ")
+}
+
+// parseLeadingID reads the ID prefix of a line printed by dmenuLine.
+func parseLeadingID(out []byte) (int, error) {
+	fields := bytes.Fields(out)
+	if len(fields) == 0 {
+		return 0, ErrCanceled
+	}
+	return parseSelectedID(fields[0])
+}
@@ -0,0 +1,180 @@
+package popup
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"stashclip/internal/config"
+	"stashclip/internal/i18n"
+)
+
+const defaultCommandLine = "{id}  [{kind}] {text}"
+
+// CommandBackend runs a user-configured picker command.
+type CommandBackend struct {
+	cfg       config.PopupBackend
+	output    *regexp.Regexp
+	exitCodes map[int]Action
+}
+
+// NewCommandBackend validates cfg and returns a backend for it.
+func NewCommandBackend(cfg config.PopupBackend) (*CommandBackend, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("popup backend: name is required")
+	}
+	if len(cfg.Command) == 0 {
+		return nil, fmt.Errorf("popup backend %s: command is required", cfg.Name)
+	}
+	switch cfg.Input {
+	case "", "stdin", "args":
+	default:
+		return nil, fmt.Errorf("popup backend %s: invalid input: %s", cfg.Name, cfg.Input)
+	}
+	if cfg.Line == "" {
+		cfg.Line = defaultCommandLine
+	}
+	if cfg.Input == "args" && len(cfg.ItemArgs) == 0 {
+		cfg.ItemArgs = []string{"{id}", "{text}"}
+	}
+	pattern := cfg.Output
+	if pattern == "" {
+		pattern = `^\s*(\d+)`
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("popup backend %s: %w", cfg.Name, err)
+	}
+	if re.NumSubexp() < 1 {
+		return nil, fmt.Errorf("popup backend %s: output needs a capture group", cfg.Name)
+	}
+	b := &CommandBackend{cfg: cfg, output: re, exitCodes: map[int]Action{}}
+	for codeText, name := range cfg.ExitCodes {
+		code, err := strconv.Atoi(codeText)
+		if err != nil || code <= 0 {
+			return nil, fmt.Errorf("popup backend %s: invalid exit code: %s", cfg.Name, codeText)
+		}
+		action := Action(name)
+		switch action {
+		case ActionTransform, ActionMenu, ActionDelete, ActionPin, ActionEdit, ActionCopyPlain, ActionSnippets:
+		default:
+			return nil, fmt.Errorf("popup backend %s: invalid action: %s", cfg.Name, name)
+		}
+		b.exitCodes[code] = action
+	}
+	return b, nil
+}
+
+// Name returns the configured backend name.
+func (b *CommandBackend) Name() string { return b.cfg.Name }
+
+// Available reports whether the command is installed.
+func (b *CommandBackend) Available() bool {
+	_, err := exec.LookPath(b.cfg.Command[0])
+	return err == nil
+}
+
+// Select runs the picker with items and parses the chosen ID.
+func (b *CommandBackend) Select(items []Item) (Selection, error) {
+	out, code, err := b.run(i18n.T("popup.select"), items)
+	if err != nil {
+		return Selection{}, err
+	}
+	action := ActionCopy
+	if code != 0 {
+		var ok bool
+		if action, ok = b.exitCodes[code]; !ok {
+			return Selection{}, ErrCanceled
+		}
+	}
+	if action == ActionSnippets {
+		return Selection{Action: action}, nil
+	}
+	id, err := b.parseID(out)
+	if err != nil {
+		return Selection{}, err
+	}
+	return Selection{ID: id, Action: action}, nil
+}
+
+// Choose shows options as items numbered from 1 and returns the chosen key.
+func (b *CommandBackend) Choose(prompt string, options []Option) (string, error) {
+	items := make([]Item, 0, len(options))
+	for i, o := range options {
+		items = append(items, Item{ID: i + 1, Text: o.Label})
+	}
+	out, code, err := b.run(prompt, items)
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return "", ErrCanceled
+	}
+	id, err := b.parseID(out)
+	if err != nil {
+		return "", err
+	}
+	if id > len(options) {
+		return "", errorf("err.popup.selection")
+	}
+	return options[id-1].Key, nil
+}
+
+// Ask runs the picker without items and returns the first line it prints,
+// which is the typed text in dmenu-like pickers.
+func (b *CommandBackend) Ask(prompt string) (string, error) {
+	out, code, err := b.run(prompt, nil)
+	if err != nil {
+		return "", err
+	}
+	if code != 0 {
+		return "", ErrCanceled
+	}
+	line, _, _ := strings.Cut(string(out), "\n")
+	return string(trimNewline([]byte(line))), nil
+}
+
+func (b *CommandBackend) run(prompt string, items []Item) ([]byte, int, error) {
+	args := make([]string, 0, len(b.cfg.Command)-1)
+	for _, arg := range b.cfg.Command[1:] {
+		args = append(args, strings.ReplaceAll(arg, "{prompt}", prompt))
+	}
+	var stdin strings.Builder
+	for _, item := range items {
+		if b.cfg.Input == "args" {
+			for _, arg := range b.cfg.ItemArgs {
+				args = append(args, expandItem(arg, item))
+			}
+			continue
+		}
+		stdin.WriteString(expandItem(b.cfg.Line, item))
+		stdin.WriteByte('\n')
+	}
+	return runPicker(b.cfg.Command[0], args, stdin.String())
+}
+
+func (b *CommandBackend) parseID(out []byte) (int, error) {
+	m := b.output.FindSubmatch(out)
+	if m == nil {
+		return 0, errorf("err.popup.selection")
+	}
+	return parseSelectedID(m[1])
+}
+
+func expandItem(tmpl string, item Item) string {
+	date := ""
+	if !item.AddedAt.IsZero() {
+		date = item.AddedAt.Format(time.RFC3339)
+	}
+	return strings.NewReplacer(
+		"{id}", strconv.Itoa(item.ID),
+		"{kind}", item.Kind,
+		"{date}", date,
+		"{text}", sanitize(item.Text),
+		"{summary}", Summary(item.Text, summaryWidth),
+		"{details}", Details(item, time.Now()),
+	).Replace(tmpl)
+}
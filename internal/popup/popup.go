@@ -3,153 +3,207 @@ package popup
 import (
 	"errors"
 	"fmt"
-	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 	"time"
+
+	"stashclip/internal/i18n"
 )
 
 // ErrCanceled indicates the user closed/canceled the popup.
 var ErrCanceled = errors.New("popup canceled")
 
+// errorf returns the catalog message for key, prefixed with the popup area.
+func errorf(key string, args ...any) error {
+	return fmt.Errorf(i18n.T("err.prefix", "popup")+i18n.T(key), args...)
+}
+
+// fail wraps err with the popup prefix.
+func fail(err error) error {
+	return fmt.Errorf(i18n.T("err.prefix", "popup")+"%w", err)
+}
+
 // Item is a selectable clipboard entry shown in the popup.
 type Item struct {
 	ID      int
 	AddedAt time.Time
 	Text    string
+	// Kind is a short content type badge such as "url" or "code/go".
+	Kind   string
+	Pinned bool
+	// Uses is how many times the entry was captured or picked.
+	Uses int
+	// Focus marks the item highlighted when the popup opens, where the
+	// backend supports it.
+	Focus bool
 }
 
-// Select opens a popup and returns the selected item ID (1-based).
-func Select(items []Item) (int, error) {
-	if len(items) == 0 {
-		return 0, fmt.Errorf("popup error: no entries available")
-	}
+// Action is what the user asked to do with the selected item.
+type Action string
 
-	name := preferredProvider()
-	switch name {
-	case "yad":
-		return selectWithYad(items)
-	case "zenity":
-		return selectWithZenity(items)
-	case "kdialog":
-		return selectWithKdialog(items)
-	default:
-		return 0, fmt.Errorf("popup error: no supported popup backend found (install one of: yad, zenity, kdialog)")
-	}
+const (
+	// ActionCopy copies the selected item.
+	ActionCopy Action = "copy"
+	// ActionTransform opens the transform menu for the selected item.
+	ActionTransform Action = "transform"
+	// ActionMenu opens the quick action menu for the selected item.
+	ActionMenu Action = "menu"
+	// ActionDelete removes the selected item from history.
+	ActionDelete Action = "delete"
+	// ActionPin toggles the pinned state of the selected item.
+	ActionPin Action = "pin"
+	// ActionEdit opens the selected item in $EDITOR and copies the result.
+	ActionEdit Action = "edit"
+	// ActionCopyPlain copies the selected item without trailing newlines.
+	ActionCopyPlain Action = "copy-plain"
+	// ActionSnippets switches to the snippets; the selection has no item.
+	ActionSnippets Action = "snippets"
+)
+
+// Terminal reports whether the action closes the popup. After other
+// actions the popup reopens on the same item.
+func (a Action) Terminal() bool {
+	return a != ActionDelete && a != ActionPin
 }
 
-func preferredProvider() string {
-	if forced := strings.ToLower(strings.TrimSpace(os.Getenv("STASHCLIP_POPUP_PROVIDER"))); forced != "" {
-		if hasCommand(forced) {
-			return forced
-		}
-		return ""
-	}
-	for _, p := range []string{"yad", "zenity", "kdialog"} {
-		if hasCommand(p) {
-			return p
-		}
+// Selection is the item chosen in the popup and the requested action.
+type Selection struct {
+	ID     int
+	Action Action
+	// IDs holds every chosen item, in list order, when the backend
+	// supports multi-select and more than one item was chosen. ID is the first.
+	IDs []int
+}
+
+// multiSelection builds a selection from the chosen IDs.
+func multiSelection(ids []int, action Action) Selection {
+	sel := Selection{ID: ids[0], Action: action}
+	if len(ids) > 1 {
+		sel.IDs = ids
 	}
-	return ""
+	return sel
 }
 
-func hasCommand(name string) bool {
-	_, err := exec.LookPath(name)
-	return err == nil
+// Option is an entry of a Choose menu.
+type Option struct {
+	Key   string
+	Label string
 }
 
-func selectWithYad(items []Item) (int, error) {
-	args := []string{
-		"--list",
-		"--title=Stashclip",
-		"--text=Selecione um item para copiar",
-		"--width=980",
-		"--height=600",
-		"--button=Copiar:0",
-		"--button=Fechar:1",
-		"--column=ID:NUM",
-		"--column=Data:TEXT",
-		"--column=Texto:TEXT",
-		"--print-column=1",
-		"--separator=\n",
-	}
-	for _, item := range items {
-		args = append(args, strconv.Itoa(item.ID), item.AddedAt.Format(time.RFC3339), sanitize(item.Text))
+// Select opens a popup with the preferred backend and returns the selected
+// item ID (1-based) and action.
+func Select(items []Item) (Selection, error) {
+	if len(items) == 0 {
+		return Selection{}, errorf("err.empty")
 	}
-
-	cmd := exec.Command("yad", args...)
-	out, err := cmd.Output()
+	b, err := Preferred()
 	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return 0, ErrCanceled
-		}
-		return 0, fmt.Errorf("popup error: %w", err)
+		return Selection{}, err
 	}
-	return parseSelectedID(out)
-}
-
-func selectWithZenity(items []Item) (int, error) {
-	args := []string{
-		"--list",
-		"--title=Stashclip",
-		"--text=Selecione um item para copiar",
-		"--width=980",
-		"--height=600",
-		"--ok-label=Copiar",
-		"--cancel-label=Fechar",
-		"--column=ID",
-		"--column=Data",
-		"--column=Texto",
-		"--hide-column=1",
-		"--print-column=1",
+	return b.Select(items)
+}
+
+// Choose opens a menu with options in the preferred backend and returns the
+// chosen option key.
+func Choose(prompt string, options []Option) (string, error) {
+	if len(options) == 0 {
+		return "", errorf("err.popup.options")
 	}
-	for _, item := range items {
-		args = append(args, strconv.Itoa(item.ID), item.AddedAt.Format(time.RFC3339), sanitize(item.Text))
+	b, err := Preferred()
+	if err != nil {
+		return "", err
 	}
-
-	cmd := exec.Command("zenity", args...)
-	out, err := cmd.Output()
+	key, err := b.Choose(prompt, options)
 	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return 0, ErrCanceled
+		return "", err
+	}
+	for _, o := range options {
+		if o.Key == key {
+			return key, nil
 		}
-		return 0, fmt.Errorf("popup error: %w", err)
 	}
-	return parseSelectedID(out)
+	return "", errorf("err.popup.selection")
 }
 
-func selectWithKdialog(items []Item) (int, error) {
-	args := []string{
-		"--title", "Stashclip",
-		"--menu", "Selecione um item para copiar",
+// Asker is implemented by backends that can ask for a line of text.
+type Asker interface {
+	Ask(prompt string) (string, error)
+}
+
+// Ask asks for a line of text in the preferred backend.
+func Ask(prompt string) (string, error) {
+	b, err := Preferred()
+	if err != nil {
+		return "", err
 	}
-	for _, item := range items {
-		args = append(args, strconv.Itoa(item.ID), fmt.Sprintf("%s  %s", item.AddedAt.Format(time.RFC3339), sanitize(item.Text)))
+	a, ok := b.(Asker)
+	if !ok {
+		return "", errorf("err.popup.ask", b.Name())
 	}
+	return a.Ask(prompt)
+}
 
-	cmd := exec.Command("kdialog", args...)
+// runPicker runs a picker command with optional stdin and returns its
+// output and exit code. A non-zero exit code is not an error.
+func runPicker(name string, args []string, stdin string) ([]byte, int, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
 	out, err := cmd.Output()
 	if err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
-			return 0, ErrCanceled
+			return out, exitErr.ExitCode(), nil
 		}
-		return 0, fmt.Errorf("popup error: %w", err)
+		return nil, 0, fail(err)
 	}
-	return parseSelectedID(out)
+	return out, 0, nil
+}
+
+func hasCommand(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
 }
 
 func parseSelectedID(out []byte) (int, error) {
 	id, err := strconv.Atoi(strings.TrimSpace(string(out)))
 	if err != nil || id <= 0 {
-		return 0, fmt.Errorf("popup error: invalid selection")
+		return 0, errorf("err.popup.selection")
 	}
 	return id, nil
 }
 
+// focusIndex returns the 0-based position of the focused item, or -1.
+func focusIndex(items []Item) int {
+	for i, item := range items {
+		if item.Focus {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseSelectedIDs parses one or more IDs separated by newlines or "|".
+func parseSelectedIDs(out []byte) ([]int, error) {
+	fields := strings.FieldsFunc(string(out), func(r rune) bool {
+		return r == '|' || r == '\n' || r == '\r'
+	})
+	if len(fields) == 0 {
+		return nil, errorf("err.popup.selection")
+	}
+	ids := make([]int, 0, len(fields))
+	for _, f := range fields {
+		id, err := parseSelectedID([]byte(f))
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 func sanitize(text string) string {
 	s := strings.ReplaceAll(text, "\n", "\\n")
 	s = strings.ReplaceAll(s, "\t", "\\t")
@@ -0,0 +1,87 @@
+package popup
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"stashclip/internal/i18n"
+)
+
+// summaryWidth is the number of characters kept in list summaries.
+const summaryWidth = 100
+
+// Summary returns the first non-blank line of text, truncated to width
+// characters. An ellipsis marks truncated text or further lines.
+func Summary(text string, width int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	first := strings.TrimSpace(strings.ReplaceAll(lines[0], "\t", " "))
+	more := len(lines) > 1
+	if utf8.RuneCountInString(first) > width {
+		first = string([]rune(first)[:width-1])
+		more = true
+	}
+	if more {
+		first += "…"
+	}
+	return first
+}
+
+// LineCount returns the number of lines in text.
+func LineCount(text string) int {
+	return strings.Count(strings.TrimRight(text, "\n"), "\n") + 1
+}
+
+// Size formats a byte count.
+func Size(n int) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
+
+// Age formats the time since t relative to now, like "3 min ago".
+func Age(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return i18n.T("age.now")
+	case d < time.Hour:
+		return i18n.T("age.minutes", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return i18n.T("age.hours", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		days := int(d / (24 * time.Hour))
+		if days == 1 {
+			return i18n.T("age.day")
+		}
+		return i18n.T("age.days", days)
+	default:
+		return t.Local().Format(i18n.T("age.date"))
+	}
+}
+
+// Details returns the line count, size and age of an item.
+func Details(item Item, now time.Time) string {
+	lines := LineCount(item.Text)
+	key := "lines.many"
+	if lines == 1 {
+		key = "lines.one"
+	}
+	details := fmt.Sprintf("%s · %s · %s", i18n.T(key, lines), Size(len(item.Text)), Age(item.AddedAt, now))
+	if item.Uses > 1 {
+		details += " · " + i18n.T("uses", item.Uses)
+	}
+	return details
+}
+
+// Preview is the full text of an item below a header with its details.
+func Preview(item Item, now time.Time) string {
+	header := fmt.Sprintf("#%d · %s · %s", item.ID, pinnedKind(item), Details(item, now))
+	return header + "\n\n" + item.Text
+}
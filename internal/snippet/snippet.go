@@ -0,0 +1,174 @@
+// Package snippet loads text templates and expands their placeholders.
+package snippet
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"stashclip/internal/config"
+)
+
+// Snippet is a named text template.
+type Snippet struct {
+	Name string
+	Text string
+}
+
+// DefaultDir is the "snippets" directory next to config.json.
+func DefaultDir() string {
+	path := config.DefaultPath()
+	if path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(path), "snippets")
+}
+
+// Load returns the snippets of the directory, one per file named after the
+// snippet (the extension is dropped), and of the config, sorted by name.
+// Config snippets replace files with the same name.
+func Load(cfg config.Snippets) ([]Snippet, error) {
+	dir := cfg.Dir
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	byName := map[string]string{}
+	if dir != "" {
+		files, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+			if err != nil {
+				return nil, err
+			}
+			name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+			// Editors end files with a newline that is not part of the text.
+			byName[name] = strings.TrimSuffix(string(data), "\n")
+		}
+	}
+	for _, s := range cfg.Items {
+		if s.Name == "" {
+			return nil, fmt.Errorf("snippet without name")
+		}
+		byName[s.Name] = s.Text
+	}
+	out := make([]Snippet, 0, len(byName))
+	for name, text := range byName {
+		out = append(out, Snippet{Name: name, Text: text})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// Lookup returns the snippet called name.
+func Lookup(snippets []Snippet, name string) (Snippet, bool) {
+	for _, s := range snippets {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Snippet{}, false
+}
+
+// Expander fills placeholders:
+//
+//	{{date}}, {{time}}, {{datetime}}  current date and time
+//	{{date:LAYOUT}}                   current time in a Go layout
+//	{{clipboard}}                     current clipboard text
+//	{{uuid}}                          random UUID
+//	{{env:NAME}}                      environment variable
+//	{{prompt:Label}}                  value asked to the user, once per label
+type Expander struct {
+	Clipboard func() (string, error)
+	// Ask prompts for the value of a field.
+	Ask func(label string) (string, error)
+	Now func() time.Time
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*([a-z]+)(?::([^}]*))?\s*\}\}`)
+
+// Expand returns text with its placeholders filled in.
+func (e Expander) Expand(text string) (string, error) {
+	now := time.Now()
+	if e.Now != nil {
+		now = e.Now()
+	}
+	answers := map[string]string{}
+	var firstErr error
+	out := placeholder.ReplaceAllStringFunc(text, func(m string) string {
+		if firstErr != nil {
+			return m
+		}
+		parts := placeholder.FindStringSubmatch(m)
+		value, err := e.value(parts[1], parts[2], now, answers)
+		if err != nil {
+			firstErr = err
+			return m
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+func (e Expander) value(name, arg string, now time.Time, answers map[string]string) (string, error) {
+	switch name {
+	case "date":
+		if arg != "" {
+			return now.Format(arg), nil
+		}
+		return now.Format("2006-01-02"), nil
+	case "time":
+		return now.Format("15:04"), nil
+	case "datetime":
+		return now.Format("2006-01-02 15:04"), nil
+	case "uuid":
+		return newUUID()
+	case "env":
+		return os.Getenv(arg), nil
+	case "clipboard":
+		if e.Clipboard == nil {
+			return "", errors.New("clipboard not available")
+		}
+		return e.Clipboard()
+	case "prompt":
+		label := strings.TrimSpace(arg)
+		if v, ok := answers[label]; ok {
+			return v, nil
+		}
+		if e.Ask == nil {
+			return "", fmt.Errorf("cannot ask for %q", label)
+		}
+		v, err := e.Ask(label)
+		if err != nil {
+			return "", err
+		}
+		answers[label] = v
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown placeholder: {{%s}}", name)
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"stashclip/internal/classify"
+)
+
+// schemaVersion is the version of store.json written by this build.
+//
+// Versions 0 and 1 are the bare JSON arrays of entries written before the
+// envelope: version 0 has only the text and time, version 1 adds the
+// detected kind, the pin and the tags. Version 2 wraps the entries in an
+// envelope with the version. Version 3 adds the last use time and the use
+// count.
+const schemaVersion = 3
+
+// envelope is the on-disk layout of store.json since version 2.
+type envelope struct {
+	Version int             `json:"version"`
+	Entries json.RawMessage `json:"entries"`
+}
+
+// migration upgrades the entries of a file from version from to from+1.
+// Migrations work on plain JSON values rather than Entry, so later changes
+// to Entry do not change what they do.
+type migration struct {
+	from int
+	run  func(entries []map[string]any) ([]map[string]any, error)
+}
+
+// migrations run in order; each version below schemaVersion needs one.
+var migrations = []migration{
+	{from: 0, run: migrateKinds},
+	{from: 1, run: migrateEnvelope},
+	{from: 2, run: migrateUsage},
+}
+
+// migrateKinds detects the kind of entries saved before kinds existed.
+func migrateKinds(entries []map[string]any) ([]map[string]any, error) {
+	for _, e := range entries {
+		if kind, _ := e["Kind"].(string); kind != "" {
+			continue
+		}
+		text, _ := e["Text"].(string)
+		class := classify.Classify(text)
+		e["Kind"] = class.Kind
+		if class.Language != "" {
+			e["Language"] = class.Language
+		}
+	}
+	return entries, nil
+}
+
+// migrateEnvelope changes no entry: version 2 only wraps them.
+func migrateEnvelope(entries []map[string]any) ([]map[string]any, error) {
+	return entries, nil
+}
+
+// migrateUsage counts each entry as used once, when it was added.
+func migrateUsage(entries []map[string]any) ([]map[string]any, error) {
+	for _, e := range entries {
+		if _, ok := e["LastUsed"]; !ok {
+			e["LastUsed"] = e["AddedAt"]
+		}
+		if _, ok := e["Uses"]; !ok {
+			e["Uses"] = 1
+		}
+	}
+	return entries, nil
+}
+
+// bareVersion tells the two bare array layouts apart: version 1 entries
+// always have a kind.
+func bareVersion(data []byte) int {
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil || len(entries) == 0 {
+		return 1
+	}
+	if _, ok := entries[0]["Kind"]; ok {
+		return 1
+	}
+	return 0
+}
+
+// decodeVersioned returns the version of a store file and its entries.
+func decodeVersioned(data []byte) (int, json.RawMessage, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return bareVersion(data), data, nil
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return 0, nil, err
+	}
+	if env.Version < 2 {
+		return 0, nil, errors.New("missing schema version")
+	}
+	if len(env.Entries) == 0 {
+		env.Entries = json.RawMessage("[]")
+	}
+	return env.Version, env.Entries, nil
+}
+
+// migrate upgrades entries from version to schemaVersion.
+func migrate(version int, raw json.RawMessage) (json.RawMessage, error) {
+	var entries []map[string]any
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		var err error
+		if entries, err = m.run(entries); err != nil {
+			return nil, fmt.Errorf("migrating from version %d: %w", m.from, err)
+		}
+	}
+	return json.Marshal(entries)
+}
+
+// backupPath is where the file is copied before it is migrated from
+// version.
+func backupPath(path string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", path, version)
+}
+
+// writeBackup copies data next to path, keeping an earlier backup of the
+// same version since it is the closest to the original.
+func writeBackup(path string, version int, data []byte) (string, error) {
+	backup := backupPath(path, version)
+	f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return backup, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	return backup, f.Close()
+}
@@ -0,0 +1,308 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"stashclip/internal/classify"
+)
+
+// backupDays is the number of daily backups kept of store.json.
+const backupDays = 7
+
+// errNewer marks a file written by a newer version, which is never
+// treated as damaged.
+var errNewer = errors.New("newer than the supported schema version")
+
+// Report describes a store file as Check found it.
+type Report struct {
+	Path    string
+	Version int
+	Entries int
+	// Invalid counts entries without text, which Repair drops.
+	Invalid int
+	// Damage is why the file cannot be read; Salvageable counts the
+	// entries Repair would keep.
+	Damage      error
+	Salvageable int
+	Backups     []string
+}
+
+// Repaired describes the outcome of Repair.
+type Repaired struct {
+	Entries int
+	Dropped int
+	// Quarantine is where the original file was moved, empty when there
+	// was nothing to repair.
+	Quarantine string
+}
+
+// decodeFile decodes a whole store file, migrating older versions.
+func decodeFile(data []byte) (int, []Entry, error) {
+	version, raw, err := decodeVersioned(data)
+	if err != nil {
+		return 0, nil, err
+	}
+	if version > schemaVersion {
+		return version, nil, fmt.Errorf("schema version %d is %w (%d)", version, errNewer, schemaVersion)
+	}
+	if version < schemaVersion {
+		if raw, err = migrate(version, raw); err != nil {
+			return version, nil, err
+		}
+	}
+	var entries []Entry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return version, nil, err
+	}
+	return version, entries, nil
+}
+
+// salvage returns every readable entry of a damaged store file: those
+// before the point where it is truncated or malformed, except entries that
+// are not objects or have no text.
+func salvage(data []byte) []Entry {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil
+	}
+	if tok == json.Delim('{') {
+		for {
+			key, err := dec.Token()
+			if err != nil {
+				return nil
+			}
+			if key == "entries" {
+				break
+			}
+			if _, ok := key.(string); !ok {
+				return nil
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil
+			}
+		}
+		if tok, err = dec.Token(); err != nil {
+			return nil
+		}
+	}
+	if tok != json.Delim('[') {
+		return nil
+	}
+	var entries []Entry
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			break
+		}
+		var e Entry
+		if err := json.Unmarshal(raw, &e); err != nil || e.Text == "" {
+			continue
+		}
+		if e.Kind == "" {
+			class := classify.Classify(e.Text)
+			e.Kind, e.Language = class.Kind, class.Language
+		}
+		entries = append(entries, withUsage(e))
+	}
+	return entries
+}
+
+// recoverFile moves a damaged file aside and replaces it with the entries
+// salvaged from data.
+func recoverFile(path string, data []byte) ([]Entry, string, error) {
+	entries := salvage(data)
+	quarantine, err := moveAside(path, "corrupt")
+	if err != nil {
+		return nil, "", err
+	}
+	if err := writeEntries(path, entries); err != nil {
+		return nil, "", err
+	}
+	return entries, quarantine, nil
+}
+
+// moveAside renames path with a label and the current time, such as
+// store.json.corrupt-20060102-150405, adding a counter rather than
+// replacing an earlier file of the same second.
+func moveAside(path, label string) (string, error) {
+	base := fmt.Sprintf("%s.%s-%s", path, label, time.Now().Format("20060102-150405"))
+	dest := base
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(dest); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		dest = fmt.Sprintf("%s-%d", base, n)
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// writeEntries writes entries to path in the current schema.
+func writeEntries(path string, entries []Entry) error {
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return writeJSON(path, envelope{Version: schemaVersion, Entries: raw})
+}
+
+// Check reads the store file at path without changing it.
+func Check(path string) (Report, error) {
+	report := Report{Path: path, Backups: Backups(path)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			report.Version = schemaVersion
+			return report, nil
+		}
+		return report, err
+	}
+	version, entries, err := decodeFile(data)
+	report.Version = version
+	if errors.Is(err, errNewer) {
+		return report, err
+	}
+	if err != nil {
+		report.Damage = err
+		report.Salvageable = len(salvage(data))
+		return report, nil
+	}
+	report.Entries = len(entries)
+	for _, e := range entries {
+		if e.Text == "" {
+			report.Invalid++
+		}
+	}
+	return report, nil
+}
+
+// Repair rewrites the store file at path with its readable entries,
+// moving the original aside. A healthy file is left alone.
+func Repair(path string) (Repaired, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return Repaired{}, nil
+		}
+		return Repaired{}, err
+	}
+	_, entries, err := decodeFile(data)
+	if errors.Is(err, errNewer) {
+		return Repaired{}, err
+	}
+	if err != nil {
+		salvaged, quarantine, err := recoverFile(path, data)
+		if err != nil {
+			return Repaired{}, err
+		}
+		return Repaired{Entries: len(salvaged), Quarantine: quarantine}, nil
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if e.Text != "" {
+			kept = append(kept, e)
+		}
+	}
+	if len(kept) == len(entries) {
+		return Repaired{Entries: len(entries)}, nil
+	}
+	dropped := len(entries) - len(kept)
+	quarantine, err := moveAside(path, "corrupt")
+	if err != nil {
+		return Repaired{}, err
+	}
+	if err := writeEntries(path, kept); err != nil {
+		return Repaired{}, err
+	}
+	return Repaired{Entries: len(kept), Dropped: dropped, Quarantine: quarantine}, nil
+}
+
+// BackupDir returns the directory of the daily backups of the store file
+// at path.
+func BackupDir(path string) string {
+	return filepath.Join(filepath.Dir(path), "backups")
+}
+
+// Backups returns the daily backups of the store file at path, newest
+// first.
+func Backups(path string) []string {
+	matches, _ := filepath.Glob(filepath.Join(BackupDir(path), "store-*.json"))
+	sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+	return matches
+}
+
+// Restore replaces the store file at path with a backup, given as a path,
+// a file name in the backup directory or a date such as 2006-01-02. The
+// current file is moved aside and its new name returned.
+func Restore(path, backup string) (string, error) {
+	file := backup
+	if !strings.ContainsRune(backup, filepath.Separator) {
+		if _, err := time.Parse("2006-01-02", backup); err == nil {
+			backup = "store-" + backup + ".json"
+		}
+		file = filepath.Join(BackupDir(path), backup)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	_, entries, err := decodeFile(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", file, err)
+	}
+	var replaced string
+	if _, err := os.Stat(path); err == nil {
+		if replaced, err = moveAside(path, "replaced"); err != nil {
+			return "", err
+		}
+	}
+	if err := writeEntries(path, entries); err != nil {
+		return "", err
+	}
+	return replaced, nil
+}
+
+// backupDaily copies the store file into the backup directory before the
+// first save of the day and prunes backups beyond backupDays. Failures are
+// logged: they must not stop the history from being saved.
+func (s *Store) backupDaily() {
+	dir := BackupDir(s.path)
+	name := filepath.Join(dir, "store-"+time.Now().Format("2006-01-02")+".json")
+	if _, err := os.Stat(name); err == nil {
+		return
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warn("daily backup failed", "error", err)
+		}
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		s.logger.Warn("daily backup failed", "error", err)
+		return
+	}
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		s.logger.Warn("daily backup failed", "error", err)
+		return
+	}
+	s.logger.Debug("daily backup written", "path", name)
+	backups := Backups(s.path)
+	for i := backupDays; i < len(backups); i++ {
+		if err := os.Remove(backups[i]); err != nil {
+			s.logger.Warn("removing old backup failed", "path", backups[i], "error", err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	entryA = `{"Text":"a","AddedAt":"2024-01-01T10:00:00Z","Kind":"text"}`
+	entryB = `{"Text":"b","AddedAt":"2024-01-02T10:00:00Z","Kind":"text"}`
+	entryC = `{"Text":"c","AddedAt":"2024-01-03T10:00:00Z","Kind":"text"}`
+)
+
+// writeStore writes data as store.json in a temp directory.
+func writeStore(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "store.json")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func entryTexts(entries []Entry) string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Text)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestSalvage(t *testing.T) {
+	tests := []struct {
+		name, data, want string
+	}{
+		{"truncated envelope", `{"version":3,"entries":[` + entryA + `,` + entryB + `,{"Text":"c","Add`, "a,b"},
+		{"truncated array", `[` + entryA + `,` + entryB[:20], "a"},
+		{"syntax error", `{"version":3,"entries":[` + entryA + `,{"Text":"b",,},` + entryC + `]}`, "a"},
+		{"wrong field types", `{"version":3,"entries":[` + entryA + `,{"Text":5},` + entryC + `]}`, "a,c"},
+		{"no text or not an object", `{"version":3,"entries":[{"Text":""},"x",7,` + entryB + `]}`, "b"},
+		{"entries after other keys", `{"note":{"x":[1,2]},"entries":[` + entryA + `],"version":`, "a"},
+		{"not json", `garbage`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entryTexts(salvage([]byte(tt.data))); got != tt.want {
+				t.Errorf("salvaged %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRecoversDamagedFile(t *testing.T) {
+	data := `{"version":3,"entries":[` + entryA + `,` + entryB + `,{"Text":"c`
+	path := writeStore(t, data)
+
+	s, err := NewWithPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := entryTexts(s.List()); got != "a,b" {
+		t.Errorf("recovered entries %q, want a,b", got)
+	}
+	quarantine := s.Recovered()
+	if !regexp.MustCompile(`^` + regexp.QuoteMeta(path) + `\.corrupt-\d{8}-\d{6}$`).MatchString(quarantine) {
+		t.Errorf("quarantine name %q", quarantine)
+	}
+	if kept, err := os.ReadFile(quarantine); err != nil || string(kept) != data {
+		t.Errorf("quarantine does not hold the original file: %v", err)
+	}
+	report, err := Check(path)
+	if err != nil || report.Damage != nil || report.Entries != 2 || report.Version != schemaVersion {
+		t.Errorf("after recovery: %+v, %v", report, err)
+	}
+}
+
+func TestMoveAsideCounter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "store.json")
+	var names []string
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(path, []byte(fmt.Sprint(i)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		name, err := moveAside(path, "corrupt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	// Within one second the names differ by a counter.
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(path) + `\.corrupt-\d{8}-\d{6}(-\d+)?$`)
+	seen := map[string]bool{}
+	for i, name := range names {
+		if seen[name] || !pattern.MatchString(name) {
+			t.Errorf("quarantine name %q (all: %v)", name, names)
+		}
+		seen[name] = true
+		if data, _ := os.ReadFile(name); string(data) != fmt.Sprint(i) {
+			t.Errorf("%s holds %q, want %d", name, data, i)
+		}
+	}
+}
+
+func TestWrongVersion(t *testing.T) {
+	for _, data := range []string{
+		`{"version":1,"entries":[` + entryA + `]}`,
+		`{"version":"3","entries":[` + entryA + `]}`,
+		`{"entries":[` + entryA + `]}`,
+	} {
+		path := writeStore(t, data)
+		report, err := Check(path)
+		if err != nil || report.Damage == nil || report.Salvageable != 1 {
+			t.Errorf("Check(%s) = %+v, %v; want damage with 1 salvageable", data, report, err)
+		}
+		after, _ := os.ReadFile(path)
+		if string(after) != data {
+			t.Errorf("Check changed %s", data)
+		}
+	}
+}
+
+func TestRepair(t *testing.T) {
+	healthy := `{"version":3,"entries":[` + entryA + `]}`
+	path := writeStore(t, healthy)
+	r, err := Repair(path)
+	if err != nil || r.Quarantine != "" || r.Entries != 1 {
+		t.Errorf("Repair(healthy) = %+v, %v", r, err)
+	}
+	if after, _ := os.ReadFile(path); string(after) != healthy {
+		t.Errorf("healthy file was rewritten")
+	}
+
+	path = writeStore(t, `{"version":3,"entries":[`+entryA+`,{"Text":"","AddedAt":"2024-01-01T10:00:00Z"},`+entryB+`]}`)
+	r, err = Repair(path)
+	if err != nil || r.Entries != 2 || r.Dropped != 1 || !strings.Contains(r.Quarantine, ".corrupt-") {
+		t.Errorf("Repair(empty entry) = %+v, %v", r, err)
+	}
+
+	path = writeStore(t, `[`+entryA+`,`+entryB+`,`)
+	r, err = Repair(path)
+	if err != nil || r.Entries != 2 || r.Quarantine == "" {
+		t.Errorf("Repair(truncated) = %+v, %v", r, err)
+	}
+	s, err := NewWithPath(path)
+	if err != nil || entryTexts(s.List()) != "a,b" || s.Recovered() != "" {
+		t.Errorf("after Repair: %v, %v", entryTexts(s.List()), err)
+	}
+}
+
+func TestBackupDailyPrunes(t *testing.T) {
+	path := writeStore(t, `{"version":3,"entries":[`+entryA+`]}`)
+	dir := BackupDir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for day := 1; day <= backupDays+2; day++ {
+		name := filepath.Join(dir, fmt.Sprintf("store-2024-01-%02d.json", day))
+		if err := os.WriteFile(name, []byte("[]"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := NewWithPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("b"); err != nil {
+		t.Fatal(err)
+	}
+	backups := Backups(path)
+	if len(backups) != backupDays {
+		t.Fatalf("%d backups kept, want %d: %v", len(backups), backupDays, backups)
+	}
+	today := filepath.Join(dir, "store-"+time.Now().Format("2006-01-02")+".json")
+	if backups[0] != today {
+		t.Errorf("newest backup %s, want %s", backups[0], today)
+	}
+	if data, _ := os.ReadFile(today); !bytes.Contains(data, []byte(`"a"`)) || bytes.Contains(data, []byte(`"b"`)) {
+		t.Errorf("today's backup is not the file before the save: %s", data)
+	}
+	if oldest := filepath.Base(backups[len(backups)-1]); oldest != "store-2024-01-04.json" {
+		t.Errorf("oldest backup kept %s, want store-2024-01-04.json", oldest)
+	}
+
+	// Later saves the same day keep the first backup.
+	if err := s.Add("c"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(today); bytes.Contains(data, []byte(`"b"`)) {
+		t.Errorf("today's backup was replaced")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	current := `{"version":3,"entries":[` + entryC + `]}`
+	path := writeStore(t, current)
+	dir := BackupDir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	backups := map[string]string{
+		"store-2024-01-01.json": `{"version":3,"entries":[` + entryA + `]}`,
+		"store-2024-01-02.json": `[{"Text":"b","AddedAt":"2024-01-02T10:00:00Z"}]`,
+	}
+	for name, data := range backups {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct{ backup, want string }{
+		{"2024-01-01", "a"},
+		{"store-2024-01-02.json", "b"},
+		{filepath.Join(dir, "store-2024-01-01.json"), "a"},
+	} {
+		before, _ := os.ReadFile(path)
+		replaced, err := Restore(path, tt.backup)
+		if err != nil {
+			t.Fatalf("Restore(%s): %v", tt.backup, err)
+		}
+		if kept, _ := os.ReadFile(replaced); !bytes.Equal(kept, before) || !strings.Contains(replaced, ".replaced-") {
+			t.Errorf("Restore(%s) moved the current file to %s", tt.backup, replaced)
+		}
+		s, err := NewWithPath(path)
+		if err != nil || entryTexts(s.List()) != tt.want {
+			t.Errorf("after Restore(%s): %q, %v; want %s", tt.backup, entryTexts(s.List()), err, tt.want)
+		}
+	}
+
+	before, _ := os.ReadFile(path)
+	for _, missing := range []string{"2023-12-31", "store-nope.json", filepath.Join(dir, "gone.json")} {
+		if _, err := Restore(path, missing); err == nil {
+			t.Errorf("Restore(%s) succeeded", missing)
+		}
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, before) {
+		t.Errorf("a failed Restore changed the store file")
+	}
+}
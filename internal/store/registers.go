@@ -0,0 +1,153 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"stashclip/internal/logging"
+)
+
+// Register is a named slot holding a text, like a vim register.
+type Register struct {
+	Name      string
+	Text      string
+	UpdatedAt time.Time
+}
+
+// validRegister matches register names: letters, digits, "_", "-" and ".".
+var validRegister = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Registers keeps named registers apart from the rolling history. They are
+// never trimmed.
+type Registers struct {
+	mu     sync.Mutex
+	regs   map[string]Register
+	path   string
+	logger *slog.Logger
+}
+
+// NewRegisters returns the registers stored next to the default history.
+func NewRegisters() (*Registers, error) {
+	return NewRegistersWithPath(DefaultRegistersPath())
+}
+
+// NewRegistersWithPath returns registers backed by a specific on-disk path.
+func NewRegistersWithPath(path string) (*Registers, error) {
+	r := &Registers{regs: map[string]Register{}, path: path, logger: logging.Discard()}
+	if path == "" {
+		return r, nil
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DefaultRegistersPath returns the default registers location, in the
+// directory of the history.
+func DefaultRegistersPath() string {
+	path := DefaultPath()
+	if path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(path), "registers.json")
+}
+
+// SetLogger sets the logger used for register diagnostics.
+func (r *Registers) SetLogger(logger *slog.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	r.logger = logger
+}
+
+// Set stores text in the named register, replacing its previous text.
+func (r *Registers) Set(name, text string) error {
+	if !validRegister.MatchString(name) {
+		return fmt.Errorf("invalid register name: %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.regs[name] = Register{Name: name, Text: text, UpdatedAt: time.Now()}
+	return r.save()
+}
+
+// Get returns the named register.
+func (r *Registers) Get(name string) (Register, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reg, ok := r.regs[name]
+	return reg, ok
+}
+
+// Delete removes the named register.
+func (r *Registers) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.regs[name]; !ok {
+		return fmt.Errorf("no register named %q", name)
+	}
+	delete(r.regs, name)
+	return r.save()
+}
+
+// List returns every register sorted by name.
+func (r *Registers) List() []Register {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sorted()
+}
+
+func (r *Registers) sorted() []Register {
+	out := make([]Register, 0, len(r.regs))
+	for _, reg := range r.regs {
+		out = append(out, reg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (r *Registers) load() error {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	var regs []Register
+	if err := json.Unmarshal(data, &regs); err != nil {
+		return err
+	}
+	for _, reg := range regs {
+		r.regs[reg.Name] = reg
+	}
+	return nil
+}
+
+func (r *Registers) save() error {
+	if r.path == "" {
+		return nil
+	}
+	regs := r.sorted()
+	if err := writeJSON(r.path, regs); err != nil {
+		return err
+	}
+	r.logger.Debug("registers saved", "path", r.path, "registers", len(regs))
+	return nil
+}
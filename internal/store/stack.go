@@ -0,0 +1,119 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"stashclip/internal/logging"
+)
+
+// Stack is a stash of texts pushed and popped in last-in, first-out
+// order, kept apart from the history.
+type Stack struct {
+	mu     sync.Mutex
+	items  []Entry
+	path   string
+	logger *slog.Logger
+}
+
+// NewStack returns the stack stored next to the default history.
+func NewStack() (*Stack, error) {
+	return NewStackWithPath(DefaultStackPath())
+}
+
+// NewStackWithPath returns a stack backed by a specific on-disk path.
+func NewStackWithPath(path string) (*Stack, error) {
+	s := &Stack{path: path, logger: logging.Discard()}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.items); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// DefaultStackPath returns the default stack location, in the directory of
+// the history.
+func DefaultStackPath() string {
+	path := DefaultPath()
+	if path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(path), "stack.json")
+}
+
+// SetLogger sets the logger used for stack diagnostics.
+func (s *Stack) SetLogger(logger *slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	s.logger = logger
+}
+
+// Push puts text on top of the stack.
+func (s *Stack) Push(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = append(s.items, Entry{Text: text, AddedAt: time.Now()})
+	return s.save()
+}
+
+// Peek returns the text on top of the stack without removing it.
+func (s *Stack) Peek() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.items) == 0 {
+		return "", false
+	}
+	return s.items[len(s.items)-1].Text, true
+}
+
+// Pop removes the top of the stack and returns its text.
+func (s *Stack) Pop() (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.items) == 0 {
+		return "", false, nil
+	}
+	top := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return top.Text, true, s.save()
+}
+
+// Len returns the number of stashed texts.
+func (s *Stack) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.items)
+}
+
+func (s *Stack) save() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := writeJSON(s.path, s.items); err != nil {
+		return err
+	}
+	s.logger.Debug("stack saved", "path", s.path, "items", len(s.items))
+	return nil
+}
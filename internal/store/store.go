@@ -3,16 +3,99 @@ package store
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
+
+	"stashclip/internal/classify"
+	"stashclip/internal/logging"
+	"stashclip/internal/tags"
 )
 
+// maxEntries is the number of unpinned entries kept in history.
+const maxEntries = 200
+
+// ErrGone is returned for an entry listed earlier that is no longer stored.
+var ErrGone = errors.New("entry no longer in history")
+
 // Entry represents a stored clipboard item.
 type Entry struct {
 	Text    string
 	AddedAt time.Time
+	// LastUsed is when the text was last captured or picked, and Uses how
+	// many times.
+	LastUsed time.Time
+	Uses     int
+	Kind     classify.Kind `json:",omitempty"`
+	Language string        `json:",omitempty"`
+	Pinned   bool          `json:",omitempty"`
+	Tags     []string      `json:",omitempty"`
+}
+
+// withUsage fills the usage of an entry saved or imported without it, as
+// a single use when it was added.
+func withUsage(e Entry) Entry {
+	if e.LastUsed.IsZero() {
+		e.LastUsed = e.AddedAt
+	}
+	if e.Uses < 1 {
+		e.Uses = 1
+	}
+	return e
+}
+
+// used records another use of the entry now.
+func (e *Entry) used() {
+	e.LastUsed = time.Now()
+	e.Uses++
+}
+
+// merge folds another copy of the text into e: the earlier capture, the
+// later use, the uses of both, a pin of either and all their tags.
+func (e *Entry) merge(other Entry) {
+	if other.AddedAt.Before(e.AddedAt) {
+		e.AddedAt = other.AddedAt
+	}
+	if other.LastUsed.After(e.LastUsed) {
+		e.LastUsed = other.LastUsed
+	}
+	e.Uses += other.Uses
+	e.Pinned = e.Pinned || other.Pinned
+	e.Tags = tags.Normalize(append(append([]string(nil), e.Tags...), other.Tags...))
+}
+
+// DedupeMode is how Add treats a text already in the history.
+type DedupeMode string
+
+const (
+	// DedupeConsecutive counts a text equal to the latest entry as a use
+	// of it. It is the default.
+	DedupeConsecutive DedupeMode = "consecutive"
+	// DedupeGlobal counts a text found anywhere in the history as a use of
+	// that entry and moves it to the top.
+	DedupeGlobal DedupeMode = "global"
+	// DedupeKeepAll stores every capture as a new entry.
+	DedupeKeepAll DedupeMode = "keep-all"
+)
+
+// ParseDedupe validates a dedupe mode name; "" is DedupeConsecutive.
+func ParseDedupe(name string) (DedupeMode, bool) {
+	switch DedupeMode(name) {
+	case "", DedupeConsecutive:
+		return DedupeConsecutive, true
+	case DedupeGlobal, DedupeKeepAll:
+		return DedupeMode(name), true
+	}
+	return "", false
+}
+
+// Classification returns the detected content type of the entry.
+func (e Entry) Classification() classify.Result {
+	return classify.Result{Kind: e.Kind, Language: e.Language}
 }
 
 // Store keeps clipboard entries in memory.
@@ -20,6 +103,15 @@ type Store struct {
 	mu      sync.Mutex
 	entries []Entry
 	path    string
+	logger  *slog.Logger
+	rules   *tags.Rules
+	dedupe  DedupeMode
+	// recovered is where a damaged file was moved when it was last loaded.
+	recovered string
+	// onRemove is told about removed entries once they are saved, and
+	// removed holds their indexes until then.
+	onRemove func(indexes []int)
+	removed  []int
 }
 
 // New returns a store backed by the default on-disk path.
@@ -29,7 +121,7 @@ func New() (*Store, error) {
 
 // NewWithPath returns a store backed by a specific on-disk path.
 func NewWithPath(path string) (*Store, error) {
-	s := &Store{path: path}
+	s := &Store{path: path, logger: logging.Discard(), dedupe: DedupeConsecutive}
 	if path == "" {
 		return s, nil
 	}
@@ -51,20 +143,291 @@ func DefaultPath() string {
 	return filepath.Join(home, ".local", "share", "stashclip", "store.json")
 }
 
-// Add inserts a new entry unless it is a consecutive duplicate.
-func (s *Store) Add(text string) {
+// SetLogger sets the logger used for store diagnostics.
+func (s *Store) SetLogger(logger *slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	s.logger = logger
+}
+
+// SetTagRules sets the rules tagging entries when they are added or
+// imported.
+func (s *Store) SetTagRules(rules *tags.Rules) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rules = rules
+}
+
+// SetDedupe sets how Add treats texts already in the history.
+func (s *Store) SetDedupe(mode DedupeMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dedupe = mode
+}
+
+// Add inserts a new entry. A text already in the history, as the dedupe
+// mode decides, counts as a use of its entry instead.
+func (s *Store) Add(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.duplicate(text); i >= 0 {
+		s.logger.Debug("counting duplicate as a use", "bytes", len(text), "mode", s.dedupe)
+		s.use(i)
+		return s.save()
+	}
+
+	class := classify.Classify(text)
+	now := time.Now()
+	entry := Entry{Text: text, AddedAt: now, LastUsed: now, Uses: 1, Kind: class.Kind, Language: class.Language}
+	entry.Tags = s.rules.For(text, class.Kind)
+	s.entries = append(s.entries, entry)
+	s.trim()
+	return s.save()
+}
+
+// duplicate returns the index of the entry text repeats, or -1.
+func (s *Store) duplicate(text string) int {
+	switch s.dedupe {
+	case DedupeKeepAll:
+		return -1
+	case DedupeGlobal:
+		for i := len(s.entries) - 1; i >= 0; i-- {
+			if s.entries[i].Text == text {
+				return i
+			}
+		}
+		return -1
+	}
+	if last := len(s.entries) - 1; last >= 0 && s.entries[last].Text == text {
+		return last
+	}
+	return -1
+}
+
+// use records a use of the entry at index, moving it to the top in the
+// global dedupe mode, which counts as removing it from where it was.
+func (s *Store) use(index int) {
+	entry := s.entries[index]
+	entry.used()
+	if s.dedupe != DedupeGlobal || index == len(s.entries)-1 {
+		s.entries[index] = entry
+		return
+	}
+	s.remove(index)
+	s.entries = append(s.entries, entry)
+}
+
+// remove drops the entry at index and records it for the remove callback.
+func (s *Store) remove(index int) {
+	s.entries = append(s.entries[:index], s.entries[index+1:]...)
+	s.removed = append(s.removed, index)
+}
+
+// SetOnRemove sets a function told about entries removed from the history
+// by Delete, Clear, trimming, a move to the top or a Reload that finds them
+// gone. It gets their 0-based indexes in the order they were removed, each
+// counted after the earlier removals. It runs with the store locked, once
+// the change is saved, and must not call the store.
+func (s *Store) SetOnRemove(f func(indexes []int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onRemove = f
+}
+
+// find returns the current index of an entry listed earlier, matched by its
+// text and capture time, or -1 when it is gone.
+func (s *Store) find(e Entry) int {
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if s.entries[i].Text == e.Text && s.entries[i].AddedAt.Equal(e.AddedAt) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Touch records that the entry at index (0-based) was picked.
+func (s *Store) Touch(index int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if len(s.entries) > 0 && s.entries[len(s.entries)-1].Text == text {
+	if index < 0 || index >= len(s.entries) {
+		return fmt.Errorf("index out of range: %d", index+1)
+	}
+	s.use(index)
+	return s.save()
+}
+
+// TouchEntry is Touch for an entry listed earlier, wherever it is now.
+func (s *Store) TouchEntry(e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := s.find(e)
+	if index < 0 {
+		return ErrGone
+	}
+	s.use(index)
+	return s.save()
+}
+
+// trim drops the oldest unpinned entries beyond maxEntries.
+func (s *Store) trim() {
+	unpinned := 0
+	for _, e := range s.entries {
+		if !e.Pinned {
+			unpinned++
+		}
+	}
+	drop := unpinned - maxEntries
+	if drop <= 0 {
 		return
 	}
+	s.logger.Debug("trimming history", "dropped", drop)
+	for i := 0; drop > 0 && i < len(s.entries); {
+		if s.entries[i].Pinned {
+			i++
+			continue
+		}
+		s.remove(i)
+		drop--
+	}
+}
+
+// Delete removes the entry at index (0-based).
+func (s *Store) Delete(index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if index < 0 || index >= len(s.entries) {
+		return fmt.Errorf("index out of range: %d", index+1)
+	}
+	s.remove(index)
+	return s.save()
+}
+
+// DeleteEntry removes an entry listed earlier, wherever it is now, and
+// returns the index (0-based) it had.
+func (s *Store) DeleteEntry(e Entry) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := s.find(e)
+	if index < 0 {
+		return -1, ErrGone
+	}
+	s.remove(index)
+	return index, s.save()
+}
+
+// Insert puts entry at index (0-based), such as to undo a deletion. An
+// index past the end appends.
+func (s *Store) Insert(index int, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if index < 0 {
+		return fmt.Errorf("index out of range: %d", index+1)
+	}
+	if index > len(s.entries) {
+		index = len(s.entries)
+	}
+	s.entries = append(s.entries[:index], append([]Entry{entry}, s.entries[index:]...)...)
+	s.trim()
+	return s.save()
+}
+
+// Import adds entries from another history, keeping their times, and
+// orders the history by time: the time of the last use in the global
+// dedupe mode, which moves used entries to the top, and of the capture
+// otherwise. Their kinds are detected again and the tag rules add to their
+// tags. In the global mode a text already in the history, or imported
+// twice, is merged into one entry.
+func (s *Store) Import(entries []Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byUse := s.dedupe == DedupeGlobal
+	held := map[string]int{}
+	if byUse {
+		for i, e := range s.entries {
+			held[e.Text] = i
+		}
+	}
+	for _, e := range entries {
+		class := classify.Classify(e.Text)
+		e.Kind, e.Language = class.Kind, class.Language
+		e.Tags = tags.Normalize(append(s.rules.For(e.Text, class.Kind), e.Tags...))
+		e = withUsage(e)
+		if i, ok := held[e.Text]; ok {
+			s.entries[i].merge(e)
+			continue
+		}
+		s.entries = append(s.entries, e)
+		if byUse {
+			held[e.Text] = len(s.entries) - 1
+		}
+	}
+	sort.SliceStable(s.entries, func(i, j int) bool {
+		if byUse {
+			return s.entries[i].LastUsed.Before(s.entries[j].LastUsed)
+		}
+		return s.entries[i].AddedAt.Before(s.entries[j].AddedAt)
+	})
+	s.trim()
+	return s.save()
+}
+
+// TogglePin pins or unpins the entry at index (0-based) and returns the new state.
+// Pinned entries are never trimmed from history.
+func (s *Store) TogglePin(index int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	s.entries = append(s.entries, Entry{Text: text, AddedAt: time.Now()})
-	if len(s.entries) > 200 {
-		s.entries = s.entries[len(s.entries)-200:]
+	if index < 0 || index >= len(s.entries) {
+		return false, fmt.Errorf("index out of range: %d", index+1)
 	}
-	_ = s.save()
+	s.entries[index].Pinned = !s.entries[index].Pinned
+	return s.entries[index].Pinned, s.save()
+}
+
+// TogglePinEntry is TogglePin for an entry listed earlier, wherever it is now.
+func (s *Store) TogglePinEntry(e Entry) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := s.find(e)
+	if index < 0 {
+		return false, ErrGone
+	}
+	s.entries[index].Pinned = !s.entries[index].Pinned
+	return s.entries[index].Pinned, s.save()
+}
+
+// Tag adds and removes tags of the entry at index (0-based) and returns
+// its new tags.
+func (s *Store) Tag(index int, add, remove []string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if index < 0 || index >= len(s.entries) {
+		return nil, fmt.Errorf("index out of range: %d", index+1)
+	}
+	var kept []string
+	for _, t := range s.entries[index].Tags {
+		if !tags.Has(remove, t) {
+			kept = append(kept, t)
+		}
+	}
+	s.entries[index].Tags = tags.Normalize(append(kept, add...))
+	return s.entries[index].Tags, s.save()
 }
 
 // List returns a copy of all entries.
@@ -77,13 +440,71 @@ func (s *Store) List() []Entry {
 	return out
 }
 
+// Len returns the number of entries.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// Reload reads the entries again from disk, picking up changes made by
+// other processes.
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := s.entries
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.removed = append(s.removed, gone(before, s.entries)...)
+	s.notifyRemoved()
+	return nil
+}
+
+// gone returns the indexes of the entries of before that after lacks,
+// matched by text and capture time, last first so that each stays valid
+// after the removals before it.
+func gone(before, after []Entry) []int {
+	type key struct {
+		text string
+		at   int64
+	}
+	left := make(map[key]int, len(after))
+	for _, e := range after {
+		left[key{e.Text, e.AddedAt.UnixNano()}]++
+	}
+	var out []int
+	for i := len(before) - 1; i >= 0; i-- {
+		k := key{before[i].Text, before[i].AddedAt.UnixNano()}
+		if left[k] > 0 {
+			left[k]--
+			continue
+		}
+		out = append(out, i)
+	}
+	return out
+}
+
+// Recovered returns where the store file was moved when it was found
+// damaged on load, or "" when it was not.
+func (s *Store) Recovered() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.recovered
+}
+
 // Clear removes all entries.
-func (s *Store) Clear() {
+func (s *Store) Clear() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.entries = nil
-	_ = s.save()
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		s.remove(i)
+	}
+	return s.save()
 }
 
 func (s *Store) load() error {
@@ -97,28 +518,66 @@ func (s *Store) load() error {
 		}
 		return err
 	}
-	var entries []Entry
-	if err := json.Unmarshal(data, &entries); err != nil {
-		return err
+	version, entries, err := decodeFile(data)
+	if errors.Is(err, errNewer) {
+		return fmt.Errorf("%s: %w", s.path, err)
+	}
+	if err != nil {
+		entries, quarantine, rerr := recoverFile(s.path, data)
+		if rerr != nil {
+			return fmt.Errorf("recovering %s: %w", s.path, rerr)
+		}
+		s.entries, s.recovered = entries, quarantine
+		s.logger.Warn("store file was damaged, kept the readable entries",
+			"error", err, "entries", len(entries), "quarantine", quarantine)
+		return nil
 	}
 	s.entries = entries
+	if version < schemaVersion {
+		backup, err := writeBackup(s.path, version, data)
+		if err != nil {
+			return fmt.Errorf("backing up %s: %w", s.path, err)
+		}
+		s.logger.Info("store migrated", "from", version, "to", schemaVersion, "backup", backup)
+		return s.save()
+	}
 	return nil
 }
 
 func (s *Store) save() error {
-	if s.path == "" {
-		return nil
+	if s.path != "" {
+		s.backupDaily()
+		if err := writeEntries(s.path, s.entries); err != nil {
+			return err
+		}
+		s.logger.Debug("store saved", "path", s.path, "entries", len(s.entries))
+	}
+	s.notifyRemoved()
+	return nil
+}
+
+// notifyRemoved passes the recorded removals to the remove callback.
+func (s *Store) notifyRemoved() {
+	removed := s.removed
+	s.removed = nil
+	if len(removed) > 0 && s.onRemove != nil {
+		s.onRemove(removed)
 	}
-	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+}
+
+// writeJSON replaces the file at path with v encoded as JSON, through a
+// temp file so readers never see a partial write.
+func writeJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	data, err := json.Marshal(s.entries)
+	data, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
-	tmpPath := s.path + ".tmp"
+	tmpPath := path + ".tmp"
 	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
 		return err
 	}
-	return os.Rename(tmpPath, s.path)
+	return os.Rename(tmpPath, path)
 }
@@ -0,0 +1,324 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"stashclip/internal/classify"
+	"stashclip/internal/config"
+	"stashclip/internal/tags"
+)
+
+// copyFixture copies a testdata file to store.json in a temp directory and
+// returns its path and original bytes.
+func copyFixture(t *testing.T, name string) (string, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "store.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+func TestMigrateFixtures(t *testing.T) {
+	first := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	second := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		fixture string
+		version int
+		pinned  bool
+		tags    []string
+	}{
+		{fixture: "v0.json", version: 0},
+		{fixture: "v1.json", version: 1, pinned: true, tags: []string{"work"}},
+		{fixture: "v2.json", version: 2, pinned: true, tags: []string{"work"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			path, original := copyFixture(t, tt.fixture)
+			s, err := NewWithPath(path)
+			if err != nil {
+				t.Fatalf("NewWithPath: %v", err)
+			}
+			entries := s.List()
+			if len(entries) != 2 {
+				t.Fatalf("got %d entries, want 2", len(entries))
+			}
+			want := []struct {
+				text  string
+				kind  classify.Kind
+				added time.Time
+			}{
+				{"https://example.com/page", classify.KindURL, first},
+				{"hello world", classify.KindText, second},
+			}
+			for i, w := range want {
+				e := entries[i]
+				if e.Text != w.text || e.Kind != w.kind || !e.AddedAt.Equal(w.added) {
+					t.Errorf("entry %d = %q %q %v, want %q %q %v", i, e.Text, e.Kind, e.AddedAt, w.text, w.kind, w.added)
+				}
+				if !e.LastUsed.Equal(w.added) || e.Uses != 1 {
+					t.Errorf("entry %d usage = %v %d, want %v 1", i, e.LastUsed, e.Uses, w.added)
+				}
+			}
+			if entries[0].Pinned != tt.pinned {
+				t.Errorf("pinned = %v, want %v", entries[0].Pinned, tt.pinned)
+			}
+			if fmt.Sprint(entries[1].Tags) != fmt.Sprint(tt.tags) {
+				t.Errorf("tags = %v, want %v", entries[1].Tags, tt.tags)
+			}
+
+			backup, err := os.ReadFile(backupPath(path, tt.version))
+			if err != nil {
+				t.Fatalf("backup: %v", err)
+			}
+			if !bytes.Equal(backup, original) {
+				t.Errorf("backup differs from the original file")
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			version, _, err := decodeVersioned(data)
+			if err != nil || version != schemaVersion {
+				t.Errorf("saved version = %d (%v), want %d", version, err, schemaVersion)
+			}
+		})
+	}
+}
+
+func TestNewerVersionRefused(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	data := []byte(fmt.Sprintf(`{"version":%d,"entries":[{"Text":"a","AddedAt":"2024-01-01T10:00:00Z"}]}`, schemaVersion+1))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := NewWithPath(path)
+	if !errors.Is(err, errNewer) {
+		t.Fatalf("NewWithPath error = %v, want errNewer", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil || !bytes.Equal(after, data) {
+		t.Errorf("newer file was changed: %v", err)
+	}
+	if matches, _ := filepath.Glob(path + ".corrupt-*"); len(matches) > 0 {
+		t.Errorf("newer file was quarantined: %v", matches)
+	}
+}
+
+// texts returns the entry texts with their use counts, such as "a:2".
+func texts(s *Store) []string {
+	var out []string
+	for _, e := range s.List() {
+		out = append(out, fmt.Sprintf("%s:%d", e.Text, e.Uses))
+	}
+	return out
+}
+
+func TestDedupeModes(t *testing.T) {
+	tests := []struct {
+		mode DedupeMode
+		want []string
+	}{
+		{DedupeConsecutive, []string{"a:1", "b:1", "a:2", "c:1"}},
+		{DedupeGlobal, []string{"b:1", "a:3", "c:1"}},
+		{DedupeKeepAll, []string{"a:1", "b:1", "a:1", "a:1", "c:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			s, _ := NewWithPath("")
+			s.SetDedupe(tt.mode)
+			for _, text := range []string{"a", "b", "a", "a", "c"} {
+				if err := s.Add(text); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := texts(s); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepeatBumpsLastUsed(t *testing.T) {
+	s, _ := NewWithPath("")
+	s.SetDedupe(DedupeGlobal)
+	s.Add("a")
+	s.Add("b")
+	before := s.List()[0]
+	time.Sleep(time.Millisecond)
+	s.Add("a")
+	after := s.List()[1]
+	if after.Text != "a" || !after.LastUsed.After(before.LastUsed) || !after.AddedAt.Equal(before.AddedAt) {
+		t.Errorf("repeat = %+v, want a used after %v and added at %v", after, before.LastUsed, before.AddedAt)
+	}
+}
+
+func TestTouch(t *testing.T) {
+	for _, tt := range []struct {
+		mode DedupeMode
+		want []string
+	}{
+		{DedupeConsecutive, []string{"a:2", "b:1", "c:1"}},
+		{DedupeGlobal, []string{"b:1", "c:1", "a:2"}},
+	} {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			s, _ := NewWithPath("")
+			s.SetDedupe(tt.mode)
+			for _, text := range []string{"a", "b", "c"} {
+				s.Add(text)
+			}
+			if err := s.Touch(0); err != nil {
+				t.Fatal(err)
+			}
+			if got := texts(s); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+			if err := s.Touch(5); err == nil {
+				t.Errorf("Touch out of range succeeded")
+			}
+		})
+	}
+}
+
+func TestImportOrder(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	imported := []Entry{
+		{Text: "old, used late", AddedAt: day(1), LastUsed: day(9), Uses: 4},
+		{Text: "new, unused", AddedAt: day(5)},
+		{Text: "mid", AddedAt: day(3), LastUsed: day(3), Uses: 1},
+	}
+	for _, tt := range []struct {
+		mode DedupeMode
+		want []string
+	}{
+		{DedupeGlobal, []string{"mid:1", "new, unused:1", "old, used late:4"}},
+		{DedupeConsecutive, []string{"old, used late:4", "mid:1", "new, unused:1"}},
+	} {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			s, _ := NewWithPath("")
+			s.SetDedupe(tt.mode)
+			if err := s.Import(imported); err != nil {
+				t.Fatal(err)
+			}
+			if got := texts(s); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportMergesInGlobalMode(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	s, _ := NewWithPath("")
+	s.SetDedupe(DedupeGlobal)
+	if err := s.Import([]Entry{{Text: "a", AddedAt: day(2), LastUsed: day(4), Uses: 2, Tags: []string{"work"}}}); err != nil {
+		t.Fatal(err)
+	}
+	err := s.Import([]Entry{
+		{Text: "b", AddedAt: day(3)},
+		{Text: "a", AddedAt: day(1), LastUsed: day(5), Uses: 3, Pinned: true, Tags: []string{"sql"}},
+		{Text: "b", AddedAt: day(6)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := texts(s); fmt.Sprint(got) != "[a:5 b:2]" {
+		t.Fatalf("entries = %v, want [a:5 b:2]", got)
+	}
+	a, b := s.List()[0], s.List()[1]
+	if !a.AddedAt.Equal(day(1)) || !a.LastUsed.Equal(day(5)) || !a.Pinned || fmt.Sprint(a.Tags) != "[sql work]" {
+		t.Errorf("merged a = %+v", a)
+	}
+	if !b.AddedAt.Equal(day(3)) || !b.LastUsed.Equal(day(6)) {
+		t.Errorf("merged b = %+v", b)
+	}
+}
+
+func TestImportAppliesTagRules(t *testing.T) {
+	rules, err := tags.NewRules([]config.TagRule{{Tag: "jira", Pattern: `\b[A-Z]+-\d+\b`}, {Tag: "link", Kind: "url"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := NewWithPath("")
+	s.SetTagRules(rules)
+	err = s.Import([]Entry{
+		{Text: "fix ABC-12", AddedAt: time.Now(), Tags: []string{"work"}},
+		{Text: "https://example.com/ABC-7", AddedAt: time.Now()},
+		{Text: "plain", AddedAt: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range s.List() {
+		got = append(got, e.Text+":"+strings.Join(e.Tags, ","))
+	}
+	want := "[fix ABC-12:jira,work https://example.com/ABC-7:jira,link plain:]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("tags = %v, want %s", got, want)
+	}
+}
+
+func TestOnRemove(t *testing.T) {
+	s, _ := NewWithPath("")
+	s.SetDedupe(DedupeGlobal)
+	var removed []int
+	s.SetOnRemove(func(indexes []int) { removed = append(removed, indexes...) })
+	for i := 0; i < maxEntries+2; i++ {
+		s.Add(fmt.Sprint(i))
+	}
+	if fmt.Sprint(removed) != "[0 0]" {
+		t.Errorf("trim removed %v, want [0 0]", removed)
+	}
+
+	removed = nil
+	s.Add("5")
+	entries := s.List()
+	if idx, err := s.DeleteEntry(entries[1]); err != nil || idx != 1 {
+		t.Errorf("DeleteEntry = %d, %v", idx, err)
+	}
+	if _, err := s.DeleteEntry(entries[1]); !errors.Is(err, ErrGone) {
+		t.Errorf("second DeleteEntry error = %v, want ErrGone", err)
+	}
+	if fmt.Sprint(removed) != "[3 1]" {
+		t.Errorf("move and delete removed %v, want [3 1]", removed)
+	}
+
+	removed = nil
+	s.Clear()
+	if len(removed) != maxEntries-1 || removed[0] != maxEntries-2 {
+		t.Errorf("Clear removed %d entries starting at %v", len(removed), removed[:1])
+	}
+}
+
+func TestReloadReportsRemovals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	s, _ := NewWithPath(path)
+	for _, text := range []string{"a", "b", "c", "d"} {
+		s.Add(text)
+	}
+	var removed []int
+	s.SetOnRemove(func(indexes []int) { removed = append(removed, indexes...) })
+
+	other, _ := NewWithPath(path)
+	other.Delete(2)
+	other.Delete(0)
+	if err := s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(removed) != "[2 0]" || len(s.List()) != 2 {
+		t.Errorf("Reload removed %v, entries %v", removed, texts(s))
+	}
+}
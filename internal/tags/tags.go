@@ -0,0 +1,116 @@
+// Package tags normalizes entry tags and applies the auto-tagging rules of
+// the config.
+package tags
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"stashclip/internal/classify"
+	"stashclip/internal/config"
+)
+
+// Normalize lowercases and trims tags, drops empty ones and duplicates and
+// sorts the rest.
+func Normalize(tags []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(t), "#")))
+		t = strings.Join(strings.Fields(t), "-")
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Parse splits a comma-separated list of tags, such as "work,sql".
+func Parse(list string) []string {
+	return Normalize(strings.Split(list, ","))
+}
+
+// Has reports whether tags contains tag.
+func Has(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Badge renders tags as "#work #sql".
+func Badge(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "#" + strings.Join(tags, " #")
+}
+
+type rule struct {
+	tag     string
+	pattern *regexp.Regexp
+	kind    classify.Kind
+}
+
+// Rules tags new entries by pattern or content kind. A nil *Rules tags
+// nothing.
+type Rules struct {
+	rules []rule
+}
+
+// NewRules compiles the configured rules.
+func NewRules(cfg []config.TagRule) (*Rules, error) {
+	r := &Rules{}
+	for i, c := range cfg {
+		tag := Normalize([]string{c.Tag})
+		if len(tag) == 0 {
+			return nil, fmt.Errorf("tag rule %d: missing tag", i+1)
+		}
+		if c.Pattern == "" && c.Kind == "" {
+			return nil, fmt.Errorf("tag rule %q: needs a pattern or a kind", tag[0])
+		}
+		rl := rule{tag: tag[0]}
+		if c.Pattern != "" {
+			re, err := regexp.Compile(c.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("tag rule %q: %w", tag[0], err)
+			}
+			rl.pattern = re
+		}
+		if c.Kind != "" {
+			kind, ok := classify.ParseKind(c.Kind)
+			if !ok {
+				return nil, fmt.Errorf("tag rule %q: unknown kind: %s", tag[0], c.Kind)
+			}
+			rl.kind = kind
+		}
+		r.rules = append(r.rules, rl)
+	}
+	return r, nil
+}
+
+// For returns the tags of every rule matching text of the given kind. A
+// rule with both a pattern and a kind needs both to match.
+func (r *Rules) For(text string, kind classify.Kind) []string {
+	if r == nil {
+		return nil
+	}
+	var out []string
+	for _, rl := range r.rules {
+		if rl.kind != "" && rl.kind != kind {
+			continue
+		}
+		if rl.pattern != nil && !rl.pattern.MatchString(text) {
+			continue
+		}
+		out = append(out, rl.tag)
+	}
+	return Normalize(out)
+}
@@ -0,0 +1,78 @@
+package tags
+
+import (
+	"fmt"
+	"testing"
+
+	"stashclip/internal/classify"
+	"stashclip/internal/config"
+)
+
+func TestNormalize(t *testing.T) {
+	got := Normalize([]string{" Work ", "#SQL", "to  do", "", " # ", "work", "sql"})
+	if want := "[sql to-do work]"; fmt.Sprint(got) != want {
+		t.Errorf("Normalize = %v, want %s", got, want)
+	}
+	if got := Normalize(nil); got != nil {
+		t.Errorf("Normalize(nil) = %v", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for list, want := range map[string]string{
+		"work,sql":      "[sql work]",
+		" #Work , ,SQL": "[sql work]",
+		"":              "[]",
+		"one tag":       "[one-tag]",
+	} {
+		if got := Parse(list); fmt.Sprint(got) != want {
+			t.Errorf("Parse(%q) = %v, want %s", list, got, want)
+		}
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	rules, err := NewRules([]config.TagRule{
+		{Tag: "Jira", Pattern: `\b[A-Z]+-\d+\b`},
+		{Tag: "link", Kind: "url"},
+		{Tag: "github", Kind: "url", Pattern: `github\.com`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		kind classify.Kind
+		want string
+	}{
+		{"fix ABC-12 today", classify.KindText, "[jira]"},
+		{"https://github.com/a/b", classify.KindURL, "[github link]"},
+		{"github.com is a site", classify.KindText, "[]"},
+		{"https://example.com/ABC-7", classify.KindURL, "[jira link]"},
+		{"nothing here", classify.KindText, "[]"},
+	}
+	for _, tt := range tests {
+		if got := rules.For(tt.text, tt.kind); fmt.Sprint(got) != tt.want {
+			t.Errorf("For(%q, %s) = %v, want %s", tt.text, tt.kind, got, tt.want)
+		}
+	}
+
+	var none *Rules
+	if got := none.For("ABC-12", classify.KindText); got != nil {
+		t.Errorf("nil rules tagged %v", got)
+	}
+}
+
+func TestNewRulesErrors(t *testing.T) {
+	for _, rule := range []config.TagRule{
+		{Pattern: "x"},
+		{Tag: " # "},
+		{Tag: "a"},
+		{Tag: "a", Pattern: "("},
+		{Tag: "a", Kind: "image"},
+	} {
+		if _, err := NewRules([]config.TagRule{rule}); err == nil {
+			t.Errorf("NewRules(%+v) succeeded", rule)
+		}
+	}
+}
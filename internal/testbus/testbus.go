@@ -0,0 +1,39 @@
+// Package testbus starts a private D-Bus session bus for tests.
+package testbus
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// Start runs a session bus for the test and points
+// DBUS_SESSION_BUS_ADDRESS at it. The test is skipped when dbus-daemon is
+// not installed.
+func Start(t *testing.T) string {
+	t.Helper()
+	path, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+	cmd := exec.Command(path, "--session", "--nofork", "--print-address=1")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("starting dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	line, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading bus address: %v", err)
+	}
+	address := strings.TrimSpace(line)
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", address)
+	return address
+}
@@ -0,0 +1,231 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(Transform{Name: "trim", Description: "Trim surrounding whitespace", Apply: trim})
+	Register(Transform{Name: "collapse-whitespace", Description: "Collapse runs of whitespace into one space", Apply: collapseWhitespace})
+	Register(Transform{Name: "upper", Description: "UPPER CASE", Apply: upper})
+	Register(Transform{Name: "lower", Description: "lower case", Apply: lower})
+	Register(Transform{Name: "title", Description: "Title Case", Apply: title})
+	Register(Transform{Name: "base64-encode", Description: "Base64 encode", Apply: base64Encode})
+	Register(Transform{Name: "base64-decode", Description: "Base64 decode", Apply: base64Decode})
+	Register(Transform{Name: "url-encode", Description: "URL query encode", Apply: urlEncode})
+	Register(Transform{Name: "url-decode", Description: "URL query decode", Apply: urlDecode})
+	Register(Transform{Name: "json-pretty", Description: "Pretty-print JSON", Apply: jsonPretty})
+	Register(Transform{Name: "json-minify", Description: "Minify JSON", Apply: jsonMinify})
+	Register(Transform{Name: "xml-pretty", Description: "Pretty-print XML", Apply: xmlPretty})
+	Register(Transform{Name: "xml-minify", Description: "Minify XML", Apply: xmlMinify})
+	Register(Transform{Name: "strip-tracking", Description: "Remove tracking parameters from URLs", Apply: stripTracking})
+}
+
+func trim(text string) (string, error) {
+	return strings.TrimSpace(text), nil
+}
+
+func collapseWhitespace(text string) (string, error) {
+	return strings.Join(strings.Fields(text), " "), nil
+}
+
+func upper(text string) (string, error) {
+	return strings.ToUpper(text), nil
+}
+
+func lower(text string) (string, error) {
+	return strings.ToLower(text), nil
+}
+
+func title(text string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(text))
+	startOfWord := true
+	for _, r := range text {
+		if unicode.IsSpace(r) || r == '-' || r == '_' {
+			startOfWord = true
+			b.WriteRune(r)
+			continue
+		}
+		if startOfWord {
+			b.WriteRune(unicode.ToTitle(r))
+		} else {
+			b.WriteRune(unicode.ToLower(r))
+		}
+		startOfWord = false
+	}
+	return b.String(), nil
+}
+
+func base64Encode(text string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(text)), nil
+}
+
+func base64Decode(text string) (string, error) {
+	s := strings.Join(strings.Fields(text), "")
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if data, err := enc.DecodeString(s); err == nil {
+			if !utf8.Valid(data) {
+				return "", errors.New("decoded data is not valid UTF-8 text")
+			}
+			return string(data), nil
+		}
+	}
+	return "", errors.New("invalid base64 input")
+}
+
+func urlEncode(text string) (string, error) {
+	return url.QueryEscape(text), nil
+}
+
+func urlDecode(text string) (string, error) {
+	return url.QueryUnescape(strings.TrimSpace(text))
+}
+
+func jsonPretty(text string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(strings.TrimSpace(text)), "", "  "); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func jsonMinify(text string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(strings.TrimSpace(text))); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func xmlPretty(text string) (string, error) {
+	return reencodeXML(text, "  ")
+}
+
+func xmlMinify(text string) (string, error) {
+	return reencodeXML(text, "")
+}
+
+// reencodeXML rewrites the document dropping whitespace-only text nodes.
+// Raw tokens are used so namespace prefixes are kept as written.
+func reencodeXML(text, indent string) (string, error) {
+	dec := xml.NewDecoder(strings.NewReader(strings.TrimSpace(text)))
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	if indent != "" {
+		enc.Indent("", indent)
+	}
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+		case xml.StartElement:
+			t.Name = flattenName(t.Name)
+			attrs := make([]xml.Attr, len(t.Attr))
+			for i, a := range t.Attr {
+				attrs[i] = xml.Attr{Name: flattenName(a.Name), Value: a.Value}
+			}
+			t.Attr = attrs
+			tok = t
+		case xml.EndElement:
+			t.Name = flattenName(t.Name)
+			tok = t
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func flattenName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+	return xml.Name{Local: name.Space + ":" + name.Local}
+}
+
+var (
+	urlPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+	trackingParams = map[string]bool{
+		"fbclid": true, "gclid": true, "dclid": true, "gbraid": true, "wbraid": true,
+		"msclkid": true, "yclid": true, "igshid": true, "mc_cid": true, "mc_eid": true,
+		"_hsenc": true, "_hsmi": true, "mkt_tok": true, "ref_src": true,
+	}
+
+	// siHosts use "si" for share tracking; elsewhere it may mean anything.
+	siHosts = []string{"youtube.com", "youtu.be", "spotify.com"}
+)
+
+func stripTracking(text string) (string, error) {
+	return urlPattern.ReplaceAllStringFunc(text, cleanURL), nil
+}
+
+// cleanURL drops tracking parameters from the query of raw, keeping the
+// rest of the URL as written, in the same order and encoding.
+func cleanURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return raw
+	}
+	start := strings.Index(raw, "?")
+	end := len(raw)
+	if i := strings.Index(raw[start:], "#"); i >= 0 {
+		end = start + i
+	}
+	params := strings.Split(raw[start+1:end], "&")
+	var kept []string
+	for _, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if k, err := url.QueryUnescape(key); err == nil && isTracking(strings.ToLower(k), u.Hostname()) {
+			continue
+		}
+		kept = append(kept, param)
+	}
+	if len(kept) == len(params) {
+		return raw
+	}
+	if len(kept) == 0 {
+		return raw[:start] + raw[end:]
+	}
+	return raw[:start+1] + strings.Join(kept, "&") + raw[end:]
+}
+
+func isTracking(key, host string) bool {
+	if strings.HasPrefix(key, "utm_") || trackingParams[key] {
+		return true
+	}
+	if key != "si" {
+		return false
+	}
+	host = strings.ToLower(host)
+	for _, h := range siHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package transform
+
+import "testing"
+
+func TestCleanURL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://a.example/p?z=1&utm_source=x&a=%20b#frag", "https://a.example/p?z=1&a=%20b#frag"},
+		{"https://a.example/p?utm_source=x&fbclid=y#f", "https://a.example/p#f"},
+		{"https://a.example/p?b=2&a=1", "https://a.example/p?b=2&a=1"},
+		{"https://a.example/p?si=1&b=2", "https://a.example/p?si=1&b=2"},
+		{"https://www.youtube.com/watch?v=abc&si=XYZ", "https://www.youtube.com/watch?v=abc"},
+		{"https://youtu.be/abc?si=XYZ", "https://youtu.be/abc"},
+		{"https://open.spotify.com/track/1?si=abc", "https://open.spotify.com/track/1"},
+	}
+	for _, tt := range tests {
+		if got := cleanURL(tt.in); got != tt.want {
+			t.Errorf("cleanURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
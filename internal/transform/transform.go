@@ -0,0 +1,67 @@
+package transform
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Func converts entry text.
+type Func func(text string) (string, error)
+
+// Transform is a named text conversion.
+type Transform struct {
+	Name        string
+	Description string
+	Apply       Func
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Transform{}
+	order    []string
+)
+
+// Register adds t to the registry, replacing any transform with the same name.
+func Register(t Transform) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := registry[t.Name]; !exists {
+		order = append(order, t.Name)
+	}
+	registry[t.Name] = t
+}
+
+// Lookup returns the transform registered as name.
+func Lookup(name string) (Transform, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	t, ok := registry[name]
+	return t, ok
+}
+
+// List returns all transforms in registration order.
+func List() []Transform {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	out := make([]Transform, 0, len(order))
+	for _, name := range order {
+		out = append(out, registry[name])
+	}
+	return out
+}
+
+// Apply runs the transform registered as name on text.
+func Apply(name, text string) (string, error) {
+	t, ok := Lookup(name)
+	if !ok {
+		return "", fmt.Errorf("unknown transform: %s", name)
+	}
+	out, err := t.Apply(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return out, nil
+}
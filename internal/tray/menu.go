@@ -0,0 +1,132 @@
+package tray
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	menuPath      = dbus.ObjectPath("/MenuBar")
+	menuInterface = "com.canonical.dbusmenu"
+)
+
+// menuItem is one row of the tray menu. Rows without a label are separators.
+type menuItem struct {
+	id      int32
+	label   string
+	toggled *bool
+	enabled bool
+	run     func()
+}
+
+// layout is a dbusmenu layout node, signature (ia{sv}av).
+type layout struct {
+	ID       int32
+	Props    map[string]dbus.Variant
+	Children []dbus.Variant
+}
+
+func (m menuItem) props() map[string]dbus.Variant {
+	if m.label == "" {
+		return map[string]dbus.Variant{"type": dbus.MakeVariant("separator")}
+	}
+	props := map[string]dbus.Variant{
+		"label":   dbus.MakeVariant(m.label),
+		"enabled": dbus.MakeVariant(m.enabled),
+	}
+	if m.toggled != nil {
+		state := int32(0)
+		if *m.toggled {
+			state = 1
+		}
+		props["toggle-type"] = dbus.MakeVariant("checkmark")
+		props["toggle-state"] = dbus.MakeVariant(state)
+	}
+	return props
+}
+
+// menu implements com.canonical.dbusmenu over the items built by the tray.
+type menu struct {
+	t *Tray
+}
+
+func (m menu) GetLayout(parentID int32, _ int32, _ []string) (uint32, layout, *dbus.Error) {
+	items, revision := m.t.menuItems()
+	root := layout{ID: 0, Props: map[string]dbus.Variant{"children-display": dbus.MakeVariant("submenu")}}
+	if parentID != 0 {
+		for _, item := range items {
+			if item.id == parentID {
+				return revision, layout{ID: item.id, Props: item.props(), Children: []dbus.Variant{}}, nil
+			}
+		}
+		return revision, layout{}, dbus.NewError("com.canonical.dbusmenu.Error.UnknownId", []any{parentID})
+	}
+	root.Children = make([]dbus.Variant, 0, len(items))
+	for _, item := range items {
+		root.Children = append(root.Children, dbus.MakeVariant(layout{ID: item.id, Props: item.props(), Children: []dbus.Variant{}}))
+	}
+	return revision, root, nil
+}
+
+type itemProps struct {
+	ID    int32
+	Props map[string]dbus.Variant
+}
+
+func (m menu) GetGroupProperties(ids []int32, _ []string) ([]itemProps, *dbus.Error) {
+	items, _ := m.t.menuItems()
+	want := map[int32]bool{}
+	for _, id := range ids {
+		want[id] = true
+	}
+	out := []itemProps{}
+	for _, item := range items {
+		if len(ids) == 0 || want[item.id] {
+			out = append(out, itemProps{ID: item.id, Props: item.props()})
+		}
+	}
+	return out, nil
+}
+
+func (m menu) GetProperty(id int32, name string) (dbus.Variant, *dbus.Error) {
+	items, _ := m.t.menuItems()
+	for _, item := range items {
+		if item.id == id {
+			if v, ok := item.props()[name]; ok {
+				return v, nil
+			}
+			break
+		}
+	}
+	return dbus.Variant{}, dbus.NewError("com.canonical.dbusmenu.Error.UnknownProperty", []any{name})
+}
+
+func (m menu) Event(id int32, eventID string, _ dbus.Variant, _ uint32) *dbus.Error {
+	if eventID == "clicked" {
+		m.t.click(id)
+	}
+	return nil
+}
+
+type menuEvent struct {
+	ID        int32
+	EventID   string
+	Data      dbus.Variant
+	Timestamp uint32
+}
+
+func (m menu) EventGroup(events []menuEvent) ([]int32, *dbus.Error) {
+	for _, e := range events {
+		if e.EventID == "clicked" {
+			m.t.click(e.ID)
+		}
+	}
+	return []int32{}, nil
+}
+
+func (m menu) AboutToShow(int32) (bool, *dbus.Error) {
+	return false, nil
+}
+
+func (m menu) AboutToShowGroup([]int32) ([]int32, []int32, *dbus.Error) {
+	return []int32{}, []int32{}, nil
+}
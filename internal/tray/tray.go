@@ -0,0 +1,379 @@
+// Package tray shows a StatusNotifierItem with a DBusMenu of recent entries
+// for the daemon. Without a StatusNotifierWatcher on the bus the tray stays
+// idle and registers when a watcher appears.
+package tray
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"stashclip/internal/clipboard"
+	"stashclip/internal/i18n"
+	"stashclip/internal/logging"
+	"stashclip/internal/popup"
+	"stashclip/internal/store"
+)
+
+const (
+	itemPath      = dbus.ObjectPath("/StatusNotifierItem")
+	itemInterface = "org.kde.StatusNotifierItem"
+
+	watcherName      = "org.kde.StatusNotifierWatcher"
+	watcherPath      = dbus.ObjectPath("/StatusNotifierWatcher")
+	watcherInterface = "org.kde.StatusNotifierWatcher"
+
+	propertiesInterface = "org.freedesktop.DBus.Properties"
+
+	iconActive = "edit-paste"
+	iconPaused = "media-playback-pause"
+
+	defaultEntries = 10
+	labelWidth     = 50
+)
+
+// Menu item ids. Entries use entryBase plus their 1-based history id.
+const (
+	idPause int32 = iota + 1
+	idPopup
+	idClear
+	idEmpty
+	idSeparator1
+	idSeparator2
+	entryBase int32 = 1000
+)
+
+// Controller pauses and resumes capture.
+type Controller interface {
+	Paused() bool
+	SetPaused(paused bool)
+}
+
+// Options configures the tray.
+type Options struct {
+	Logger *slog.Logger
+	// Entries is the number of recent entries in the menu.
+	Entries int
+	// Controller backs the pause toggle; nil hides it.
+	Controller Controller
+}
+
+// Tray is a running tray item. Its methods are nil-safe.
+type Tray struct {
+	conn      *dbus.Conn
+	name      string
+	store     *store.Store
+	clipboard clipboard.ClipboardProvider
+	ctl       Controller
+	entries   int
+	logger    *slog.Logger
+
+	mu       sync.Mutex
+	revision uint32
+}
+
+// Start exports the tray item and menu and registers with the watcher.
+func Start(st *store.Store, cb clipboard.ClipboardProvider, opts Options) (*Tray, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.Discard()
+	}
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	t := &Tray{
+		conn:      conn,
+		name:      fmt.Sprintf("org.kde.StatusNotifierItem-%d-1", os.Getpid()),
+		store:     st,
+		clipboard: cb,
+		ctl:       opts.Controller,
+		entries:   opts.Entries,
+		logger:    logger,
+		revision:  1,
+	}
+	if t.entries <= 0 {
+		t.entries = defaultEntries
+	}
+	exports := []struct {
+		v     any
+		path  dbus.ObjectPath
+		iface string
+	}{
+		{item{t}, itemPath, itemInterface},
+		{properties{t.itemProps}, itemPath, propertiesInterface},
+		{menu{t}, menuPath, menuInterface},
+		{properties{t.menuProps}, menuPath, propertiesInterface},
+	}
+	for _, e := range exports {
+		if err := conn.Export(e.v, e.path, e.iface); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if _, err := conn.RequestName(t.name, dbus.NameFlagDoNotQueue); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := t.watchWatcher(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	t.register()
+	return t, nil
+}
+
+// Close removes the tray item.
+func (t *Tray) Close() error {
+	if t == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
+
+// Refresh tells the host that the entries changed.
+func (t *Tray) Refresh() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.revision++
+	revision := t.revision
+	t.mu.Unlock()
+	t.emit(menuPath, menuInterface+".LayoutUpdated", revision, int32(0))
+}
+
+// PauseChanged updates the icon, title and menu for the pause state.
+func (t *Tray) PauseChanged(bool) {
+	if t == nil {
+		return
+	}
+	for _, signal := range []string{"NewIcon", "NewTitle", "NewToolTip"} {
+		t.emit(itemPath, itemInterface+"."+signal)
+	}
+	t.Refresh()
+}
+
+// register announces the item to the watcher, if there is one.
+func (t *Tray) register() {
+	obj := t.conn.Object(watcherName, watcherPath)
+	if err := obj.Call(watcherInterface+".RegisterStatusNotifierItem", 0, t.name).Err; err != nil {
+		t.logger.Debug("no status notifier watcher", "error", err)
+		return
+	}
+	t.logger.Info("tray registered", "name", t.name)
+}
+
+// watchWatcher registers again whenever a watcher takes its name, such
+// as after the panel restarts.
+func (t *Tray) watchWatcher() error {
+	err := t.conn.AddMatchSignal(
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, watcherName),
+	)
+	if err != nil {
+		return err
+	}
+	signals := make(chan *dbus.Signal, 4)
+	t.conn.Signal(signals)
+	go func() {
+		for sig := range signals {
+			if sig.Name != "org.freedesktop.DBus.NameOwnerChanged" || len(sig.Body) < 3 {
+				continue
+			}
+			if name, _ := sig.Body[0].(string); name != watcherName {
+				continue
+			}
+			if owner, _ := sig.Body[2].(string); owner != "" {
+				t.register()
+			}
+		}
+	}()
+	return nil
+}
+
+func (t *Tray) emit(path dbus.ObjectPath, name string, values ...any) {
+	if err := t.conn.Emit(path, name, values...); err != nil {
+		t.logger.Warn("cannot emit tray signal", "signal", name, "error", err)
+	}
+}
+
+func (t *Tray) paused() bool {
+	return t.ctl != nil && t.ctl.Paused()
+}
+
+// menuItems builds the menu: the most recent entries first, then the
+// pause toggle, open popup and clear history.
+func (t *Tray) menuItems() ([]menuItem, uint32) {
+	t.mu.Lock()
+	revision := t.revision
+	t.mu.Unlock()
+
+	if err := t.store.Reload(); err != nil {
+		t.logger.Warn("cannot reload store", "error", err)
+	}
+	entries := t.store.List()
+	var items []menuItem
+	for i := len(entries) - 1; i >= 0 && len(items) < t.entries; i-- {
+		text := entries[i].Text
+		label := popup.Summary(text, labelWidth)
+		if entries[i].Pinned {
+			label = "* " + label
+		}
+		items = append(items, menuItem{id: entryBase + int32(i+1), label: label, enabled: true, run: func() { t.copy(text) }})
+	}
+	if len(items) == 0 {
+		items = append(items, menuItem{id: idEmpty, label: i18n.T("tray.empty")})
+	}
+	items = append(items, menuItem{id: idSeparator1})
+	if t.ctl != nil {
+		paused := t.ctl.Paused()
+		items = append(items, menuItem{id: idPause, label: i18n.T("tray.pause"), toggled: &paused, enabled: true, run: func() {
+			t.ctl.SetPaused(!paused)
+		}})
+	}
+	items = append(items,
+		menuItem{id: idPopup, label: i18n.T("tray.popup"), enabled: true, run: t.openPopup},
+		menuItem{id: idSeparator2},
+		menuItem{id: idClear, label: i18n.T("tray.clear"), enabled: len(entries) > 0, run: t.clear},
+	)
+	return items, revision
+}
+
+func (t *Tray) click(id int32) {
+	items, _ := t.menuItems()
+	for _, item := range items {
+		if item.id == id && item.run != nil {
+			item.run()
+			return
+		}
+	}
+}
+
+func (t *Tray) copy(text string) {
+	if err := clipboard.MarkIgnored(text); err != nil {
+		t.logger.Warn("cannot mark tray pick", "error", err)
+	}
+	if err := t.clipboard.Write(text); err != nil {
+		t.logger.Warn("cannot copy from tray", "error", err)
+	}
+}
+
+func (t *Tray) clear() {
+	if err := t.store.Clear(); err != nil {
+		t.logger.Warn("cannot clear history", "error", err)
+		return
+	}
+	t.Refresh()
+}
+
+// openPopup runs "stashclip popup" from the daemon executable.
+func (t *Tray) openPopup() {
+	exe, err := os.Executable()
+	if err != nil {
+		t.logger.Warn("cannot open popup", "error", err)
+		return
+	}
+	cmd := exec.Command(exe, "popup")
+	if err := cmd.Start(); err != nil {
+		t.logger.Warn("cannot open popup", "error", err)
+		return
+	}
+	go func() { _ = cmd.Wait() }()
+}
+
+func (t *Tray) itemProps() map[string]dbus.Variant {
+	icon, title := iconActive, "Stashclip"
+	if t.paused() {
+		icon, title = iconPaused, i18n.T("tray.title.paused")
+	}
+	tooltip := toolTip{IconName: icon, IconPixmap: []pixmap{}, Title: title, Description: i18n.T("tray.tooltip", t.store.Len())}
+	return map[string]dbus.Variant{
+		"Category":          dbus.MakeVariant("ApplicationStatus"),
+		"Id":                dbus.MakeVariant("stashclip"),
+		"Title":             dbus.MakeVariant(title),
+		"Status":            dbus.MakeVariant("Active"),
+		"IconName":          dbus.MakeVariant(icon),
+		"IconPixmap":        dbus.MakeVariant([]pixmap{}),
+		"AttentionIconName": dbus.MakeVariant(""),
+		"ToolTip":           dbus.MakeVariant(tooltip),
+		"ItemIsMenu":        dbus.MakeVariant(false),
+		"Menu":              dbus.MakeVariant(menuPath),
+	}
+}
+
+func (t *Tray) menuProps() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Version":       dbus.MakeVariant(uint32(3)),
+		"TextDirection": dbus.MakeVariant("ltr"),
+		"Status":        dbus.MakeVariant("normal"),
+		"IconThemePath": dbus.MakeVariant([]string{}),
+	}
+}
+
+type pixmap struct {
+	Width  int32
+	Height int32
+	Data   []byte
+}
+
+type toolTip struct {
+	IconName    string
+	IconPixmap  []pixmap
+	Title       string
+	Description string
+}
+
+// item implements org.kde.StatusNotifierItem.
+type item struct {
+	t *Tray
+}
+
+// Activate opens the popup on a left click.
+func (i item) Activate(x, y int32) *dbus.Error {
+	i.t.openPopup()
+	return nil
+}
+
+// SecondaryActivate toggles pause on a middle click.
+func (i item) SecondaryActivate(x, y int32) *dbus.Error {
+	if i.t.ctl != nil {
+		i.t.ctl.SetPaused(!i.t.ctl.Paused())
+	}
+	return nil
+}
+
+func (i item) ContextMenu(x, y int32) *dbus.Error {
+	return nil
+}
+
+func (i item) Scroll(delta int32, orientation string) *dbus.Error {
+	return nil
+}
+
+// properties implements org.freedesktop.DBus.Properties over a snapshot
+// function.
+type properties struct {
+	all func() map[string]dbus.Variant
+}
+
+func (p properties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	v, ok := p.all()[name]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.UnknownProperty", []any{name})
+	}
+	return v, nil
+}
+
+func (p properties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	return p.all(), nil
+}
+
+func (p properties) Set(iface, name string, _ dbus.Variant) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.PropertyReadOnly", []any{name})
+}
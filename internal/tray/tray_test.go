@@ -0,0 +1,120 @@
+package tray
+
+import (
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"stashclip/internal/store"
+	"stashclip/internal/testbus"
+)
+
+// watcher is a fake StatusNotifierWatcher.
+type watcher struct {
+	conn  *dbus.Conn
+	items chan string
+}
+
+func (w *watcher) RegisterStatusNotifierItem(service string) *dbus.Error {
+	w.items <- service
+	return nil
+}
+
+// startWatcher claims the watcher name on a new connection; closing the
+// connection drops the name, as when the panel exits.
+func startWatcher(t *testing.T) *watcher {
+	t.Helper()
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	w := &watcher{conn: conn, items: make(chan string, 4)}
+	if err := conn.Export(w, watcherPath, watcherInterface); err != nil {
+		t.Fatal(err)
+	}
+	reply, err := conn.RequestName(watcherName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("requesting %s: %v", watcherName, err)
+	}
+	return w
+}
+
+func (w *watcher) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case name := <-w.items:
+		return name
+	case <-time.After(5 * time.Second):
+		t.Fatal("no RegisterStatusNotifierItem call")
+		return ""
+	}
+}
+
+func startTray(t *testing.T, texts ...string) *Tray {
+	t.Helper()
+	st, _ := store.NewWithPath("")
+	for _, text := range texts {
+		st.Add(text)
+	}
+	tr, err := Start(st, nil, Options{})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { tr.Close() })
+	return tr
+}
+
+func TestRegister(t *testing.T) {
+	testbus.Start(t)
+	w := startWatcher(t)
+	tr := startTray(t, "first", "second")
+
+	name := w.next(t)
+	if name != tr.name {
+		t.Fatalf("registered %q, want %q", name, tr.name)
+	}
+	item := w.conn.Object(name, itemPath)
+	var id string
+	if err := item.Call(propertiesInterface+".Get", 0, itemInterface, "Id").Store(&id); err != nil || id != "stashclip" {
+		t.Errorf("Id = %q, %v", id, err)
+	}
+	var revision uint32
+	var root layout
+	err := w.conn.Object(name, menuPath).Call(menuInterface+".GetLayout", 0, int32(0), int32(-1), []string{}).Store(&revision, &root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := root.Children[0].Value().([]any)
+	if first[0].(int32) != entryBase+2 || first[1].(map[string]dbus.Variant)["label"].Value() != "second" {
+		t.Errorf("first menu row = %v, want the newest entry", first)
+	}
+}
+
+func TestReregister(t *testing.T) {
+	testbus.Start(t)
+	w := startWatcher(t)
+	tr := startTray(t)
+	w.next(t)
+
+	// The panel restarts: its watcher leaves the bus and a new one appears.
+	w.conn.Close()
+	w = startWatcher(t)
+	if name := w.next(t); name != tr.name {
+		t.Errorf("registered %q again, want %q", name, tr.name)
+	}
+}
+
+func TestNoWatcher(t *testing.T) {
+	testbus.Start(t)
+	tr := startTray(t, "text")
+	tr.Refresh()
+	tr.PauseChanged(true)
+
+	// A watcher appearing later gets the item.
+	w := startWatcher(t)
+	if name := w.next(t); name != tr.name {
+		t.Errorf("registered %q, want %q", name, tr.name)
+	}
+}
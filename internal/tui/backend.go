@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"os"
+
+	"stashclip/internal/popup"
+)
+
+func init() {
+	popup.RegisterFallback(backend{})
+}
+
+// backend exposes the terminal picker as a popup backend, used when no
+// graphical picker is available and a terminal is attached.
+type backend struct{}
+
+func (backend) Name() string { return "tui" }
+
+func (backend) Available() bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	_ = tty.Close()
+	return true
+}
+
+func (backend) Select(items []popup.Item) (popup.Selection, error) {
+	t, err := Open()
+	if err != nil {
+		return popup.Selection{}, err
+	}
+	defer t.Close()
+	return t.Select("Stashclip", items)
+}
+
+func (backend) Choose(prompt string, options []popup.Option) (string, error) {
+	t, err := Open()
+	if err != nil {
+		return "", err
+	}
+	defer t.Close()
+	return t.Choose(prompt, options)
+}
+
+func (backend) Ask(prompt string) (string, error) {
+	t, err := Open()
+	if err != nil {
+		return "", err
+	}
+	defer t.Close()
+	return t.Ask(prompt)
+}
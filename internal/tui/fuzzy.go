@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fuzzyScore matches every space-separated term of query as a
+// case-insensitive subsequence of text. Consecutive matches and matches at
+// word starts score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return 0, true
+	}
+	lower := []rune(strings.ToLower(text))
+	total := 0
+	for _, term := range terms {
+		score, ok := matchTerm([]rune(term), lower)
+		if !ok {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+func matchTerm(term, text []rune) (int, bool) {
+	score, ti, prev := 0, 0, -2
+	for i, r := range text {
+		if ti == len(term) {
+			break
+		}
+		if r != term[ti] {
+			continue
+		}
+		score++
+		if prev == i-1 {
+			score += 5
+		}
+		if i == 0 || !unicode.IsLetter(text[i-1]) && !unicode.IsDigit(text[i-1]) {
+			score += 8
+		}
+		prev = i
+		ti++
+	}
+	return score, ti == len(term)
+}
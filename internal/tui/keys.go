@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type keyKind int
+
+const (
+	keyRune keyKind = iota
+	keyEnter
+	keyEsc
+	keyBackspace
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyTab
+	keyCtrl
+	keyAlt
+	keyMouse
+)
+
+type key struct {
+	kind keyKind
+	r    rune
+	// mouse fields: button code, 1-based column/row and release flag.
+	button  int
+	x, y    int
+	release bool
+}
+
+// parseKeys decodes a chunk of terminal input. Escape sequences are assumed
+// to arrive whole, which holds for terminal emulators in practice.
+func parseKeys(b []byte) []key {
+	var keys []key
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			k, n := parseEscape(b)
+			keys = append(keys, k)
+			b = b[n:]
+			continue
+		case c == '\r' || c == '\n':
+			keys = append(keys, key{kind: keyEnter})
+		case c == '\t':
+			keys = append(keys, key{kind: keyTab})
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, key{kind: keyBackspace})
+		case c < 0x20:
+			keys = append(keys, key{kind: keyCtrl, r: rune('a' + c - 1)})
+		default:
+			r, n := utf8.DecodeRune(b)
+			keys = append(keys, key{kind: keyRune, r: r})
+			b = b[n:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+func parseEscape(b []byte) (key, int) {
+	if len(b) == 1 {
+		return key{kind: keyEsc}, 1
+	}
+	if b[1] != '[' && b[1] != 'O' {
+		r, n := utf8.DecodeRune(b[1:])
+		return key{kind: keyAlt, r: r}, 1 + n
+	}
+	// CSI: parameters end at the first byte in 0x40..0x7e
+	end := 2
+	for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+		end++
+	}
+	if end >= len(b) {
+		return key{kind: keyEsc}, len(b)
+	}
+	params, final := string(b[2:end]), b[end]
+	n := end + 1
+	switch final {
+	case 'A':
+		return key{kind: keyUp}, n
+	case 'B':
+		return key{kind: keyDown}, n
+	case 'H':
+		return key{kind: keyHome}, n
+	case 'F':
+		return key{kind: keyEnd}, n
+	case 'Z':
+		return key{kind: keyTab, r: 'Z'}, n
+	case '~':
+		switch params {
+		case "1", "7":
+			return key{kind: keyHome}, n
+		case "4", "8":
+			return key{kind: keyEnd}, n
+		case "5":
+			return key{kind: keyPageUp}, n
+		case "6":
+			return key{kind: keyPageDown}, n
+		}
+	case 'M', 'm':
+		if strings.HasPrefix(params, "<") {
+			parts := strings.Split(params[1:], ";")
+			if len(parts) == 3 {
+				button, _ := strconv.Atoi(parts[0])
+				x, _ := strconv.Atoi(parts[1])
+				y, _ := strconv.Atoi(parts[2])
+				return key{kind: keyMouse, button: button, x: x, y: y, release: final == 'm'}, n
+			}
+		}
+	}
+	return key{kind: keyCtrl, r: 0}, n
+}
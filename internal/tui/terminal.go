@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+// Terminal is an interactive session on the controlling terminal.
+// It reads and draws on /dev/tty so stdout stays free for scripts.
+type Terminal struct {
+	tty    *os.File
+	saved  syscall.Termios
+	keys   chan key
+	resize chan os.Signal
+	width  int
+	height int
+	closed bool
+
+	// state kept between Select calls so the list reopens where it was.
+	query      string
+	lastID     int
+	lastCursor int
+}
+
+// Open switches the controlling terminal to raw mode and the alternate screen.
+func Open() (*Terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, errorf("err.tui.tty")
+	}
+	t := &Terminal{tty: tty, keys: make(chan key, 16), resize: make(chan os.Signal, 1)}
+	if err := ioctl(tty.Fd(), syscall.TCGETS, unsafe.Pointer(&t.saved)); err != nil {
+		_ = tty.Close()
+		return nil, errorf("err.tui.notty")
+	}
+	raw := t.saved
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(tty.Fd(), syscall.TCSETS, unsafe.Pointer(&raw)); err != nil {
+		_ = tty.Close()
+		return nil, errorf("err.tui.raw")
+	}
+	signal.Notify(t.resize, syscall.SIGWINCH)
+	t.updateSize()
+	// alternate screen, hide cursor, SGR mouse reporting
+	t.write("\x1b[?1049h\x1b[?25l\x1b[?1000h\x1b[?1006h")
+	go t.readLoop()
+	return t, nil
+}
+
+// Close restores the terminal. It is safe to call more than once.
+func (t *Terminal) Close() error {
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	signal.Stop(t.resize)
+	t.write("\x1b[?1006l\x1b[?1000l\x1b[?25h\x1b[?1049l")
+	_ = ioctl(t.tty.Fd(), syscall.TCSETS, unsafe.Pointer(&t.saved))
+	return t.tty.Close()
+}
+
+func (t *Terminal) write(s string) {
+	_, _ = t.tty.WriteString(s)
+}
+
+func (t *Terminal) updateSize() {
+	var ws struct{ Row, Col, X, Y uint16 }
+	if err := ioctl(t.tty.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&ws)); err != nil || ws.Col == 0 {
+		t.width, t.height = 80, 24
+		return
+	}
+	t.width, t.height = int(ws.Col), int(ws.Row)
+}
+
+func (t *Terminal) readLoop() {
+	buf := make([]byte, 256)
+	for {
+		n, err := t.tty.Read(buf)
+		if err != nil {
+			close(t.keys)
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			t.keys <- k
+		}
+	}
+}
+
+func ioctl(fd uintptr, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
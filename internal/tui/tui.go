@@ -0,0 +1,426 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"stashclip/internal/i18n"
+	"stashclip/internal/popup"
+)
+
+// errorf returns the catalog message for key, prefixed with the tui area.
+func errorf(key string, args ...any) error {
+	return fmt.Errorf(i18n.T("err.prefix", "tui")+i18n.T(key), args...)
+}
+
+type row struct {
+	id     int
+	key    string
+	label  string
+	search string
+	item   *popup.Item
+}
+
+type listView struct {
+	t        *Terminal
+	title    string
+	help     string
+	rows     []row
+	filtered []int
+	cursor   int
+	offset   int
+	query    []rune
+	preview  bool
+	// marked holds the row ids marked with Tab for multi-select.
+	marked map[int]bool
+	// input makes Enter return the query, for Ask.
+	input bool
+}
+
+// Select shows items newest first with incremental fuzzy filtering and
+// returns the chosen item and action. The query and cursor position are
+// kept for the next call, so the list reopens in place after delete or pin.
+func (t *Terminal) Select(title string, items []popup.Item) (popup.Selection, error) {
+	if len(items) == 0 {
+		return popup.Selection{}, errorf("err.empty")
+	}
+	now := time.Now()
+	rows := make([]row, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		rows = append(rows, row{id: item.ID, label: itemLabel(item, now), search: item.Text, item: &items[i]})
+		if item.Focus {
+			t.lastID = item.ID
+		}
+	}
+	v := &listView{t: t, title: title, help: i18n.T("tui.help.select"), rows: rows, query: []rune(t.query), preview: true, marked: map[int]bool{}}
+	v.refilter()
+	v.cursor = t.lastCursor
+	v.move(0)
+	for i, idx := range v.filtered {
+		if v.rows[idx].id == t.lastID {
+			v.cursor = i
+		}
+	}
+
+	r, action, err := v.run(true)
+	t.query = string(v.query)
+	if err != nil {
+		return popup.Selection{}, err
+	}
+	if action == popup.ActionSnippets {
+		return popup.Selection{Action: action}, nil
+	}
+	t.lastID, t.lastCursor = r.id, v.cursor
+	if len(v.marked) > 0 && action != popup.ActionDelete && action != popup.ActionPin {
+		ids := make([]int, 0, len(v.marked))
+		for id := range v.marked {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		sel := popup.Selection{ID: ids[0], Action: action}
+		if len(ids) > 1 {
+			sel.IDs = ids
+		}
+		return sel, nil
+	}
+	return popup.Selection{ID: r.id, Action: action}, nil
+}
+
+// Choose shows options in a filterable list and returns the chosen key.
+func (t *Terminal) Choose(prompt string, options []popup.Option) (string, error) {
+	if len(options) == 0 {
+		return "", errorf("err.popup.options")
+	}
+	rows := make([]row, 0, len(options))
+	for i, o := range options {
+		rows = append(rows, row{id: i + 1, key: o.Key, label: o.Label, search: o.Key + " " + o.Label})
+	}
+	v := &listView{t: t, title: prompt, help: i18n.T("tui.help.choose"), rows: rows}
+	v.refilter()
+	r, _, err := v.run(false)
+	if err != nil {
+		return "", err
+	}
+	return r.key, nil
+}
+
+// Ask reads a line of text typed in the query field.
+func (t *Terminal) Ask(prompt string) (string, error) {
+	v := &listView{t: t, title: prompt, help: i18n.T("tui.help.ask"), input: true}
+	v.refilter()
+	r, _, err := v.run(false)
+	if err != nil {
+		return "", err
+	}
+	return r.key, nil
+}
+
+func (v *listView) run(withActions bool) (row, popup.Action, error) {
+	for {
+		v.draw()
+		var k key
+		select {
+		case <-v.t.resize:
+			v.t.updateSize()
+			continue
+		case next, ok := <-v.t.keys:
+			if !ok {
+				return row{}, "", popup.ErrCanceled
+			}
+			k = next
+		}
+
+		switch k.kind {
+		case keyEsc:
+			return row{}, "", popup.ErrCanceled
+		case keyEnter:
+			if v.input {
+				return row{key: string(v.query)}, popup.ActionCopy, nil
+			}
+			if r, ok := v.current(); ok {
+				return r, popup.ActionCopy, nil
+			}
+		case keyRune:
+			v.query = append(v.query, k.r)
+			v.refilter()
+		case keyBackspace:
+			if len(v.query) > 0 {
+				v.query = v.query[:len(v.query)-1]
+				v.refilter()
+			}
+		case keyUp:
+			v.move(-1)
+		case keyDown:
+			v.move(1)
+		case keyTab:
+			if k.r == 'Z' {
+				v.move(-1)
+				continue
+			}
+			if r, ok := v.current(); ok && v.marked != nil {
+				if v.marked[r.id] {
+					delete(v.marked, r.id)
+				} else {
+					v.marked[r.id] = true
+				}
+			}
+			v.move(1)
+		case keyPageUp:
+			v.move(-v.listHeight())
+		case keyPageDown:
+			v.move(v.listHeight())
+		case keyHome:
+			v.move(-len(v.filtered))
+		case keyEnd:
+			v.move(len(v.filtered))
+		case keyCtrl:
+			switch k.r {
+			case 'c', 'g', 'q':
+				return row{}, "", popup.ErrCanceled
+			case 'p', 'k':
+				v.move(-1)
+			case 'n':
+				v.move(1)
+			case 'u':
+				v.query = v.query[:0]
+				v.refilter()
+			}
+		case keyAlt:
+			if !withActions {
+				continue
+			}
+			if k.r == 's' {
+				return row{}, popup.ActionSnippets, nil
+			}
+			r, ok := v.current()
+			if !ok {
+				continue
+			}
+			switch k.r {
+			case 'd':
+				return r, popup.ActionDelete, nil
+			case 'p':
+				return r, popup.ActionPin, nil
+			case 't':
+				return r, popup.ActionTransform, nil
+			case 'e':
+				return r, popup.ActionEdit, nil
+			case 'c':
+				return r, popup.ActionCopyPlain, nil
+			case 'a':
+				return r, popup.ActionMenu, nil
+			}
+		case keyMouse:
+			if r, ok := v.click(k); ok {
+				return r, popup.ActionCopy, nil
+			}
+		}
+	}
+}
+
+// click handles wheel scrolling and left clicks. Clicking the highlighted
+// row chooses it.
+func (v *listView) click(k key) (row, bool) {
+	switch k.button {
+	case 64:
+		v.move(-3)
+		return row{}, false
+	case 65:
+		v.move(3)
+		return row{}, false
+	case 0:
+	default:
+		return row{}, false
+	}
+	if k.release {
+		return row{}, false
+	}
+	line := k.y - 3
+	if line < 0 || line >= v.listHeight() || k.x > v.listWidth() {
+		return row{}, false
+	}
+	target := v.offset + line
+	if target >= len(v.filtered) {
+		return row{}, false
+	}
+	if target == v.cursor {
+		return v.current()
+	}
+	v.cursor = target
+	return row{}, false
+}
+
+func (v *listView) current() (row, bool) {
+	if v.cursor < 0 || v.cursor >= len(v.filtered) {
+		return row{}, false
+	}
+	return v.rows[v.filtered[v.cursor]], true
+}
+
+func (v *listView) move(delta int) {
+	v.cursor += delta
+	if v.cursor >= len(v.filtered) {
+		v.cursor = len(v.filtered) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+}
+
+func (v *listView) refilter() {
+	type scored struct{ idx, score int }
+	var matches []scored
+	query := string(v.query)
+	for i, r := range v.rows {
+		if score, ok := fuzzyScore(query, r.search); ok {
+			matches = append(matches, scored{i, score})
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool { return matches[a].score > matches[b].score })
+	v.filtered = v.filtered[:0]
+	for _, m := range matches {
+		v.filtered = append(v.filtered, m.idx)
+	}
+	v.cursor, v.offset = 0, 0
+}
+
+func (v *listView) listHeight() int {
+	if h := v.t.height - 3; h > 0 {
+		return h
+	}
+	return 1
+}
+
+func (v *listView) listWidth() int {
+	if v.preview && v.t.width >= 60 {
+		return v.t.width * 55 / 100
+	}
+	return v.t.width
+}
+
+func (v *listView) draw() {
+	height, listH, listW := v.t.height, v.listHeight(), v.listWidth()
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+listH {
+		v.offset = v.cursor - listH + 1
+	}
+
+	var previewLines []string
+	showPreview := listW < v.t.width
+	if showPreview {
+		if r, ok := v.current(); ok && r.item != nil {
+			previewLines = previewText(*r.item, v.t.width-listW-2, listH)
+		}
+	}
+
+	var b strings.Builder
+	line := func(n int, content string) {
+		fmt.Fprintf(&b, "\x1b[%d;1H%s\x1b[0m\x1b[K", n, content)
+	}
+	line(1, fit("> "+string(v.query)+"█", v.t.width))
+	status := fmt.Sprintf("  %d/%d  %s", len(v.filtered), len(v.rows), v.title)
+	if len(v.marked) > 0 {
+		status += "  " + i18n.T("tui.marked", len(v.marked))
+	}
+	line(2, "\x1b[2m"+fit(status, v.t.width))
+	for i := 0; i < listH; i++ {
+		var cell string
+		idx := v.offset + i
+		if idx < len(v.filtered) {
+			r := v.rows[v.filtered[idx]]
+			prefix := "  "
+			if v.marked[r.id] {
+				prefix = "+ "
+			}
+			label := fit(prefix+r.label, listW)
+			if idx == v.cursor {
+				label = "\x1b[7m" + label + "\x1b[0m"
+			}
+			cell = label
+		} else {
+			cell = fit("", listW)
+		}
+		if showPreview {
+			var p string
+			if i < len(previewLines) {
+				p = previewLines[i]
+			}
+			if i == 0 {
+				p = "\x1b[1m" + p + "\x1b[0m"
+			}
+			cell += "\x1b[2m│\x1b[0m " + p
+		}
+		line(3+i, cell)
+	}
+	line(height, "\x1b[2m"+fit(v.help, v.t.width))
+	v.t.write(b.String())
+}
+
+func itemLabel(item popup.Item, now time.Time) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%4d  %-10s  ", item.ID, popup.Age(item.AddedAt, now)))
+	if item.Pinned {
+		b.WriteString("* ")
+	}
+	if item.Kind != "" {
+		b.WriteString("[" + item.Kind + "] ")
+	}
+	b.WriteString(stripControl(popup.Summary(item.Text, 200)))
+	return b.String()
+}
+
+// previewText renders the full entry wrapped to width, below a header line.
+func previewText(item popup.Item, width, height int) []string {
+	if width < 10 {
+		return nil
+	}
+	lines := strings.Split(item.Text, "\n")
+	header := fmt.Sprintf("#%d · %s · %s", item.ID, item.Kind, popup.Details(item, time.Now()))
+	out := []string{fit(header, width), ""}
+	for _, l := range lines {
+		l = strings.ReplaceAll(l, "\t", "    ")
+		runes := []rune(stripControl(l))
+		for {
+			if len(out) >= height {
+				return out
+			}
+			if len(runes) <= width {
+				out = append(out, string(runes))
+				break
+			}
+			out = append(out, string(runes[:width]))
+			runes = runes[width:]
+		}
+	}
+	return out
+}
+
+func stripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// fit truncates or pads s to exactly width runes.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) > width {
+		if width == 1 {
+			return "…"
+		}
+		return string(runes[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
@@ -29,10 +29,11 @@ if ! command -v dpkg-deb >/dev/null 2>&1; then
 fi
 
 rm -rf "$PKG_DIR"
-mkdir -p "$PKG_DIR/DEBIAN" "$PKG_DIR/usr/bin" "$PKG_DIR/usr/lib/systemd/user"
+mkdir -p "$PKG_DIR/DEBIAN" "$PKG_DIR/usr/bin" "$PKG_DIR/usr/lib/systemd/user" "$PKG_DIR/usr/share/dbus-1/interfaces"
 
 CGO_ENABLED=0 GOOS=linux GOARCH="$ARCH" go build -trimpath -ldflags="-s -w" -o "$PKG_DIR/usr/bin/stashclip" "$ROOT_DIR/cmd/stashclip"
 cp "$ROOT_DIR/packaging/ubuntu/stashclip.service" "$PKG_DIR/usr/lib/systemd/user/stashclip.service"
+cp "$ROOT_DIR/internal/dbusapi/io.github.stashclip.xml" "$PKG_DIR/usr/share/dbus-1/interfaces/io.github.stashclip.xml"
 
 cat > "$PKG_DIR/usr/bin/stashclip-popup" <<'EOF'
 #!/usr/bin/env bash